@@ -0,0 +1,125 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// archivedComponentError is returned for changes of an archived component
+func archivedComponentError(r Resource) *SdcError {
+	return &SdcError{
+		Message:   "Action is not allowed on archived component " + r.Name + ".",
+		ErrorCode: "SVC3642",
+		Status:    "Bad Action"}
+}
+
+// componentUsers returns the services which are not archived and have an
+// instance of a version of the component r
+func componentUsers(r Resource) []Resource {
+	users := []Resource{}
+	for _, s := range resourceList {
+		if s.ComponentType != "SERVICE" || s.Archived {
+			continue
+		}
+		for _, ci := range s.ComponentInstances {
+			if origin, found := instanceOrigin(ci); found && origin.InvariantID == r.InvariantID {
+				users = append(users, s)
+				break
+			}
+		}
+	}
+	return users
+}
+
+// setArchived archives or restores all the versions of a component
+func setArchived(c echo.Context, archived bool) error {
+	if _, ok, err := authorizeUser(c, "DESIGNER", "ADMIN"); !ok {
+		return err
+	}
+	i := findResourceIndex(c.Param("resourceID"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	invariantID := resourceList[i].InvariantID
+	now := time.Now().UnixNano() / 1000000
+	for j, r := range resourceList {
+		if r.InvariantID == invariantID {
+			resourceList[j].Archived = archived
+			resourceList[j].ArchiveTime = now
+		}
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func postArchive(c echo.Context) error {
+	return setArchived(c, true)
+}
+
+func postRestore(c echo.Context) error {
+	return setArchived(c, false)
+}
+
+// getArchive lists the archived resources and services
+func getArchive(c echo.Context) error {
+	list := &ResourceList{Resources: []Resource{}, Services: []Resource{}}
+	for _, r := range resourceList {
+		if !r.Archived {
+			continue
+		}
+		if r.ComponentType == "SERVICE" {
+			list.Services = append(list.Services, r)
+		} else {
+			list.Resources = append(list.Resources, r)
+		}
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// deleteComponent removes all the versions of a component from the catalog,
+// unless a service which is not archived uses it
+func deleteComponent(c echo.Context) error {
+	if _, ok, err := authorizeUser(c, "DESIGNER", "ADMIN"); !ok {
+		return err
+	}
+	i := findResourceIndex(c.Param("resourceID"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	r := resourceList[i]
+	if users := componentUsers(r); len(users) != 0 {
+		return c.JSON(http.StatusConflict, SdcError{
+			Message:   "Component " + r.Name + " cannot be deleted, it is used by service " + users[0].Name + ".",
+			ErrorCode: "SVC3642",
+			Status:    "Bad Action"})
+	}
+	kept := []Resource{}
+	for _, other := range resourceList {
+		if other.InvariantID != r.InvariantID {
+			kept = append(kept, other)
+		}
+	}
+	resourceList = kept
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,316 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ArtifactAdd describes an artifact upload in SDC
+type ArtifactAdd struct {
+	ArtifactName      string `json:"artifactName"`
+	ArtifactLabel     string `json:"artifactLabel"`
+	ArtifactType      string `json:"artifactType"`
+	ArtifactGroupType string `json:"artifactGroupType"`
+	Description       string `json:"description"`
+	PayloadData       string `json:"payloadData"`
+	Timeout           int    `json:"timeout"`
+}
+
+// Artifact describes an artifact of a component or a component instance
+type Artifact struct {
+	UniqueID            string `json:"uniqueId"`
+	ArtifactUUID        string `json:"artifactUUID"`
+	ArtifactName        string `json:"artifactName"`
+	ArtifactLabel       string `json:"artifactLabel"`
+	ArtifactDisplayName string `json:"artifactDisplayName"`
+	ArtifactType        string `json:"artifactType"`
+	ArtifactGroupType   string `json:"artifactGroupType"`
+	ArtifactVersion     string `json:"artifactVersion"`
+	ArtifactChecksum    string `json:"artifactChecksum"`
+	Description         string `json:"description"`
+	Timeout             int    `json:"timeout"`
+	EsID                string `json:"esId"`
+	CreationDate        int64  `json:"creationDate"`
+	LastUpdateDate      int64  `json:"lastUpdateDate"`
+	Payload             []byte `json:"-"`
+}
+
+// ArtifactDownload is the way SDC returns an artifact payload to the UI
+type ArtifactDownload struct {
+	ArtifactName   string `json:"artifactName"`
+	Base64Contents string `json:"base64Contents"`
+}
+
+// artifactChecksum is the base64 encoded MD5 hex digest, as SDC computes it
+func artifactChecksum(payload []byte) string {
+	sum := md5.Sum(payload)
+	return base64.StdEncoding.EncodeToString([]byte(hex.EncodeToString(sum[:])))
+}
+
+// setArtifactPayload stores a new payload in an artifact and bumps its version
+func setArtifactPayload(artifact *Artifact, payload []byte) {
+	version, _ := strconv.Atoi(artifact.ArtifactVersion)
+	artifact.ArtifactVersion = strconv.Itoa(version + 1)
+	artifact.ArtifactUUID = uuid.NewV4().String()
+	artifact.ArtifactChecksum = artifactChecksum(payload)
+	artifact.Payload = payload
+	artifact.LastUpdateDate = time.Now().UnixNano() / 1000000
+}
+
+// newArtifact builds the artifact described by an upload body, attached to
+// the component (or component instance) parentID
+func newArtifact(body ArtifactAdd, parentID string) (Artifact, *SdcError) {
+	payload, err := base64.StdEncoding.DecodeString(body.PayloadData)
+	if err != nil {
+		return Artifact{}, &SdcError{
+			Message:   "Invalid artifact payload, base64 encoding expected.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"}
+	}
+	return newArtifactFromPayload(body, payload, parentID)
+}
+
+// newArtifactFromPayload builds an artifact whose payload is already decoded
+func newArtifactFromPayload(body ArtifactAdd, payload []byte, parentID string) (Artifact, *SdcError) {
+	label := body.ArtifactLabel
+	if label == "" {
+		label = body.ArtifactName
+	}
+	label = normalizeComponentInstanceName(label)
+	if label == "" {
+		return Artifact{}, &SdcError{
+			Message:   "Missing artifact name.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"}
+	}
+	now := time.Now().UnixNano() / 1000000
+	artifact := Artifact{
+		UniqueID:            parentID + "." + label,
+		ArtifactName:        body.ArtifactName,
+		ArtifactLabel:       label,
+		ArtifactDisplayName: body.ArtifactLabel,
+		ArtifactType:        body.ArtifactType,
+		ArtifactGroupType:   body.ArtifactGroupType,
+		ArtifactVersion:     "0",
+		Description:         body.Description,
+		Timeout:             body.Timeout,
+		EsID:                parentID + "." + label,
+		CreationDate:        now,
+	}
+	setArtifactPayload(&artifact, payload)
+	return artifact, nil
+}
+
+// artifactGroup returns the artifacts of a component for a given group
+func artifactGroup(r *Resource, artifactGroupType string) *map[string]Artifact {
+	switch artifactGroupType {
+	case "DEPLOYMENT":
+		return &r.DeploymentArtifacts
+	case "INFORMATIONAL":
+		return &r.Artifacts
+	case "TOSCA":
+		return &r.ToscaArtifacts
+	case "SERVICE_API":
+		return &r.ServiceAPIArtifacts
+	}
+	return nil
+}
+
+func componentArtifactType(r Resource) string {
+	if r.ComponentType == "SERVICE" {
+		return "SERVICE"
+	}
+	return "RESOURCE"
+}
+
+// findArtifact looks an artifact up in all the groups of a component
+func findArtifact(r *Resource, artifactID string) (*map[string]Artifact, string) {
+	for _, group := range []string{"DEPLOYMENT", "INFORMATIONAL", "TOSCA", "SERVICE_API"} {
+		artifacts := artifactGroup(r, group)
+		for label, a := range *artifacts {
+			if a.UniqueID == artifactID || a.ArtifactUUID == artifactID || label == artifactID {
+				return artifacts, label
+			}
+		}
+	}
+	return nil, ""
+}
+
+func postResourceArtifact(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	body := new(ArtifactAdd)
+	if err := c.Bind(body); err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	artifact, status, sdcError := addArtifact(&resourceList[i], *body)
+	if sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, artifact)
+}
+
+// addArtifact attaches the artifact described by an upload body to a
+// component, or returns the error and its status
+func addArtifact(r *Resource, body ArtifactAdd) (Artifact, int, *SdcError) {
+	artifacts := artifactGroup(r, body.ArtifactGroupType)
+	if artifacts == nil {
+		return Artifact{}, http.StatusBadRequest, &SdcError{
+			Message:   "Invalid artifact group type " + body.ArtifactGroupType + ".",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"}
+	}
+	if sdcError := validateArtifactType(body.ArtifactType, body.ArtifactGroupType,
+		componentArtifactType(*r), r.ResourceType, body.ArtifactName); sdcError != nil {
+		return Artifact{}, http.StatusBadRequest, sdcError
+	}
+	if body.ArtifactGroupType == "DEPLOYMENT" && body.PayloadData == "" {
+		return Artifact{}, http.StatusBadRequest, &SdcError{
+			Message:   "Missing payload for deployment artifact " + body.ArtifactName + ".",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"}
+	}
+	artifact, sdcError := newArtifact(body, r.UniqueID)
+	if sdcError != nil {
+		return Artifact{}, http.StatusBadRequest, sdcError
+	}
+	if existing, _ := findArtifact(r, artifact.ArtifactLabel); existing != nil {
+		return Artifact{}, http.StatusConflict, &SdcError{
+			Message:   "Artifact " + artifact.ArtifactLabel + " already exists.",
+			ErrorCode: "SVC4125",
+			Status:    "Exists"}
+	}
+	(*artifacts)[artifact.ArtifactLabel] = artifact
+	return artifact, http.StatusOK, nil
+}
+
+func updateResourceArtifact(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	r := &resourceList[i]
+	artifacts, label := findArtifact(r, c.Param("artifactID"))
+	if artifacts == nil {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Artifact " + c.Param("artifactID") + " not found.",
+			ErrorCode: "SVC4128",
+			Status:    "Not Found"})
+	}
+	body := new(ArtifactAdd)
+	if err := c.Bind(body); err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	artifact, sdcError := updateArtifact(r, artifacts, label, *body)
+	if sdcError != nil {
+		return c.JSON(http.StatusBadRequest, sdcError)
+	}
+	setLastUpdater(r, user)
+	return c.JSON(http.StatusOK, artifact)
+}
+
+// updateArtifact applies an upload body to the artifact stored under label
+// in one of the artifact groups of a component
+func updateArtifact(r *Resource, artifacts *map[string]Artifact, label string, body ArtifactAdd) (Artifact, *SdcError) {
+	artifact := (*artifacts)[label]
+	if body.ArtifactName != "" {
+		artifact.ArtifactName = body.ArtifactName
+	}
+	if body.ArtifactType != "" {
+		artifact.ArtifactType = body.ArtifactType
+	}
+	if body.Description != "" {
+		artifact.Description = body.Description
+	}
+	if body.Timeout != 0 {
+		artifact.Timeout = body.Timeout
+	}
+	if sdcError := validateArtifactType(artifact.ArtifactType, artifact.ArtifactGroupType,
+		componentArtifactType(*r), r.ResourceType, artifact.ArtifactName); sdcError != nil {
+		return Artifact{}, sdcError
+	}
+	if body.PayloadData != "" {
+		payload, err := base64.StdEncoding.DecodeString(body.PayloadData)
+		if err != nil {
+			return Artifact{}, &SdcError{
+				Message:   "Invalid artifact payload, base64 encoding expected.",
+				ErrorCode: "SVC4000",
+				Status:    "Invalid Content"}
+		}
+		setArtifactPayload(&artifact, payload)
+	} else {
+		artifact.LastUpdateDate = time.Now().UnixNano() / 1000000
+	}
+	(*artifacts)[label] = artifact
+	return artifact, nil
+}
+
+func deleteResourceArtifact(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	artifacts, label := findArtifact(&resourceList[i], c.Param("artifactID"))
+	if artifacts == nil {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Artifact " + c.Param("artifactID") + " not found.",
+			ErrorCode: "SVC4128",
+			Status:    "Not Found"})
+	}
+	artifact := (*artifacts)[label]
+	delete(*artifacts, label)
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, artifact)
+}
+
+func downloadResourceArtifact(c echo.Context) error {
+	i := findResourceIndex(c.Param("resourceID"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	artifacts, label := findArtifact(&resourceList[i], c.Param("artifactID"))
+	if artifacts == nil {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Artifact " + c.Param("artifactID") + " not found.",
+			ErrorCode: "SVC4128",
+			Status:    "Not Found"})
+	}
+	artifact := (*artifacts)[label]
+	return c.JSON(http.StatusOK, ArtifactDownload{
+		ArtifactName:   artifact.ArtifactName,
+		Base64Contents: base64.StdEncoding.EncodeToString(artifact.Payload),
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/labstack/echo"
+	"gopkg.in/yaml.v3"
+)
+
+// ArtifactType describes an artifact type in SDC configuration
+type ArtifactType struct {
+	Type           string   `yaml:"type" json:"type"`
+	Categories     []string `yaml:"categories" json:"categories"`
+	ComponentTypes []string `yaml:"componentTypes" json:"componentTypes"`
+	ResourceTypes  []string `yaml:"resourceTypes" json:"resourceTypes"`
+	AcceptedTypes  []string `yaml:"acceptedTypes" json:"acceptedTypes"`
+}
+
+// ArtifactTypeConfiguration is the artifacts part of SDC configuration
+type ArtifactTypeConfiguration struct {
+	Artifacts []ArtifactType `yaml:"artifacts"`
+}
+
+var artifactTypeList []ArtifactType
+
+func loadArtifactTypes() error {
+	data, err := readConfiguration("ARTIFACT_TYPES_FILE", "artifact-types.yaml")
+	if err != nil {
+		return err
+	}
+	configuration := new(ArtifactTypeConfiguration)
+	if err := yaml.Unmarshal(data, configuration); err != nil {
+		return err
+	}
+	artifactTypeList = configuration.Artifacts
+	return nil
+}
+
+func findArtifactType(artifactType string) (ArtifactType, bool) {
+	for _, a := range artifactTypeList {
+		if a.Type == artifactType {
+			return a, true
+		}
+	}
+	return ArtifactType{}, false
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateArtifactType checks that an artifact may be attached to a
+// component of the given component type (RESOURCE, SERVICE,
+// RESOURCE_INSTANCE...) and resource type with the given group
+func validateArtifactType(artifactType string, artifactGroupType string,
+	componentType string, resourceType string, artifactName string) *SdcError {
+	a, found := findArtifactType(artifactType)
+	if !found ||
+		!containsString(a.Categories, artifactGroupType) ||
+		!containsString(a.ComponentTypes, componentType) ||
+		(len(a.ResourceTypes) != 0 && resourceType != "" &&
+			!containsString(a.ResourceTypes, resourceType)) {
+		return &SdcError{
+			Message:   "Invalid artifact type " + artifactType + ".",
+			ErrorCode: "SVC4122",
+			Status:    "Invalid Content"}
+	}
+	extension := strings.TrimPrefix(strings.ToLower(path.Ext(artifactName)), ".")
+	if len(a.AcceptedTypes) != 0 && !containsString(a.AcceptedTypes, extension) {
+		return &SdcError{
+			Message: "Invalid file extension for " + artifactType +
+				" artifact, allowed: " + strings.Join(a.AcceptedTypes, ", ") + ".",
+			ErrorCode: "SVC4159",
+			Status:    "Invalid Content"}
+	}
+	return nil
+}
+
+func getArtifactTypes(c echo.Context) error {
+	list := []string{}
+	for _, a := range artifactTypeList {
+		list = append(list, a.Type)
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+func getArtifactTypesConfiguration(c echo.Context) error {
+	return c.JSON(http.StatusOK, artifactTypeList)
+}
@@ -0,0 +1,288 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// SubCategory describes SubCategory model in SDC
+type SubCategory struct {
+	Name           string   `json:"name"`
+	NormalizedName string   `json:"normalizedName"`
+	UniqueID       string   `json:"uniqueId"`
+	Icons          []string `json:"icons"`
+	Groupings      string   `json:"groupings"`
+	OwnerID        string   `json:"ownerId"`
+	Empty          bool     `json:"empty"`
+	Version        string   `json:"version"`
+	Type           string   `json:"type"`
+}
+
+// Category describes Category model in SDC
+type Category struct {
+	Name           string        `json:"name"`
+	NormalizedName string        `json:"normalizedName"`
+	UniqueID       string        `json:"uniqueId"`
+	Icons          []string      `json:"icons"`
+	Subcategories  []SubCategory `json:"subcategories"`
+	OwnerID        string        `json:"ownerId"`
+	Empty          bool          `json:"empty"`
+	Type           string        `json:"type"`
+	Version        string        `json:"version"`
+}
+
+// Categories is the way to return all the categories in SDC
+type Categories struct {
+	ResourceCategories []Category `json:"resourceCategories"`
+	ServiceCategories  []Category `json:"serviceCategories"`
+	ProductCategories  []Category `json:"productCategories"`
+}
+
+// NewCategory describes the category and subcategory creation model in SDC
+type NewCategory struct {
+	Name  string   `json:"name"`
+	Icons []string `json:"icons"`
+}
+
+var categories Categories
+
+func generateInitialCategories() error {
+	data, err := readConfiguration("CATEGORIES_FILE", "categories.json")
+	if err != nil {
+		return err
+	}
+	categories = Categories{}
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return err
+	}
+	if categories.ProductCategories == nil {
+		categories.ProductCategories = []Category{}
+	}
+	return nil
+}
+
+func normalizeCategoryName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// categoryList returns the categories of a component type, given either as
+// path parameter ("resources", "services") or as componentType ("SERVICE")
+func categoryList(componentType string) (*[]Category, string) {
+	switch componentType {
+	case "resources", "RESOURCE", "":
+		return &categories.ResourceCategories, "resourceNewCategory"
+	case "services", "SERVICE":
+		return &categories.ServiceCategories, "serviceNewCategory"
+	}
+	return nil, ""
+}
+
+func findCategory(list []Category, name string) (int, bool) {
+	for i, category := range list {
+		if category.UniqueID == name ||
+			category.NormalizedName == normalizeCategoryName(name) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func findSubCategory(category Category, name string) (int, bool) {
+	for i, subCategory := range category.Subcategories {
+		if subCategory.UniqueID == name ||
+			subCategory.NormalizedName == normalizeCategoryName(name) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// validateResourceCategory checks the category of a new resource or service.
+// The category may be given either in "categories" (UI) or in "category" and
+// "subCategory" (external API); both are filled in from the catalog.
+func validateResourceCategory(resource *Resource) *SdcError {
+	categoryName := resource.Category
+	subCategoryName := resource.SubCategory
+	if len(resource.Categories) != 0 {
+		categoryName = resource.Categories[0].Name
+		if categoryName == "" {
+			categoryName = resource.Categories[0].UniqueID
+		}
+		subCategoryName = ""
+		if len(resource.Categories[0].Subcategories) != 0 {
+			subCategoryName = resource.Categories[0].Subcategories[0].Name
+			if subCategoryName == "" {
+				subCategoryName = resource.Categories[0].Subcategories[0].UniqueID
+			}
+		}
+	}
+	if categoryName == "" {
+		return nil
+	}
+	list := &categories.ResourceCategories
+	if resource.ComponentType == "SERVICE" {
+		list = &categories.ServiceCategories
+	}
+	i, found := findCategory(*list, categoryName)
+	if !found {
+		return &SdcError{
+			Message:   "Invalid category " + categoryName + ".",
+			ErrorCode: "SVC4071",
+			Status:    "Invalid Content"}
+	}
+	category := (*list)[i]
+	resource.Category = category.Name
+	if resource.ComponentType == "SERVICE" {
+		resource.SubCategory = ""
+		category.Subcategories = nil
+		resource.Categories = []Category{category}
+		return nil
+	}
+	j, found := findSubCategory(category, subCategoryName)
+	if !found {
+		return &SdcError{
+			Message:   "Invalid subcategory " + subCategoryName + " for category " + category.Name + ".",
+			ErrorCode: "SVC4071",
+			Status:    "Invalid Content"}
+	}
+	resource.SubCategory = category.Subcategories[j].Name
+	category.Subcategories = []SubCategory{category.Subcategories[j]}
+	resource.Categories = []Category{category}
+	return nil
+}
+
+// validateVspCategory checks that the category and the subcategory of a
+// VSP, given by their unique ids, are a resource category and one of its
+// subcategories
+func validateVspCategory(categoryID string, subCategoryID string) error {
+	i, found := findCategory(categories.ResourceCategories, categoryID)
+	if categoryID == "" || !found {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid category "+categoryID)
+	}
+	category := categories.ResourceCategories[i]
+	if _, found := findSubCategory(category, subCategoryID); subCategoryID == "" || !found {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid subcategory "+subCategoryID+" for category "+category.Name)
+	}
+	return nil
+}
+
+func getCategories(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"categories": categories,
+		"version":    "1.6.7",
+	})
+}
+
+func getComponentCategories(c echo.Context) error {
+	list, _ := categoryList(c.Param("componentType"))
+	if list == nil {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Unknown component type",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	return c.JSON(http.StatusOK, *list)
+}
+
+func postCategory(c echo.Context) error {
+	if _, ok, err := authorizeUser(c, "ADMIN"); !ok {
+		return err
+	}
+	list, prefix := categoryList(c.Param("componentType"))
+	if list == nil {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Unknown component type",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	newCategory := new(NewCategory)
+	if err := c.Bind(newCategory); err != nil {
+		return err
+	}
+	normalizedName := normalizeCategoryName(newCategory.Name)
+	if normalizedName == "" {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid category name format.",
+			ErrorCode: "SVC4545",
+			Status:    "Invalid Content"})
+	}
+	if _, found := findCategory(*list, normalizedName); found {
+		return c.JSON(http.StatusConflict, SdcError{
+			Message:   "Category name " + newCategory.Name + " already exists.",
+			ErrorCode: "SVC4547",
+			Status:    "Exists"})
+	}
+	category := Category{
+		Name:           strings.TrimSpace(newCategory.Name),
+		NormalizedName: normalizedName,
+		UniqueID:       prefix + "." + normalizedName,
+		Icons:          newCategory.Icons,
+	}
+	if prefix == "resourceNewCategory" {
+		category.Subcategories = []SubCategory{}
+	}
+	*list = append(*list, category)
+	return c.JSON(http.StatusCreated, category)
+}
+
+func postSubCategory(c echo.Context) error {
+	if _, ok, err := authorizeUser(c, "ADMIN"); !ok {
+		return err
+	}
+	list, prefix := categoryList(c.Param("componentType"))
+	if list == nil || prefix != "resourceNewCategory" {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Subcategories are only supported for resources",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	i, found := findCategory(*list, c.Param("categoryID"))
+	if !found {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Category not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	newSubCategory := new(NewCategory)
+	if err := c.Bind(newSubCategory); err != nil {
+		return err
+	}
+	normalizedName := normalizeCategoryName(newSubCategory.Name)
+	if normalizedName == "" {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid subcategory name format.",
+			ErrorCode: "SVC4545",
+			Status:    "Invalid Content"})
+	}
+	if _, found := findSubCategory((*list)[i], normalizedName); found {
+		return c.JSON(http.StatusConflict, SdcError{
+			Message:   "Subcategory " + newSubCategory.Name + " already exists under " + (*list)[i].Name + " category.",
+			ErrorCode: "SVC4548",
+			Status:    "Exists"})
+	}
+	subCategory := SubCategory{
+		Name:           strings.TrimSpace(newSubCategory.Name),
+		NormalizedName: normalizedName,
+		UniqueID:       (*list)[i].UniqueID + "." + normalizedName,
+		Icons:          newSubCategory.Icons,
+	}
+	(*list)[i].Subcategories = append((*list)[i].Subcategories, subCategory)
+	return c.JSON(http.StatusCreated, subCategory)
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+var nonAlphanumeric = regexp.MustCompile("[^a-z0-9]")
+
+// normalizeComponentInstanceName is the way SDC names instances in inputs
+// and TOSCA templates: "vFW VF 0" becomes "vfwvf0"
+func normalizeComponentInstanceName(name string) string {
+	return nonAlphanumeric.ReplaceAllString(strings.ToLower(name), "")
+}
+
+func findComponentInstance(instances []ComponentInstance, instanceID string) int {
+	for i, ci := range instances {
+		if ci.UniqueID == instanceID {
+			return i
+		}
+	}
+	return -1
+}
+
+// newComponentInstance creates an instance of a component, with the ids SDC
+// gives to new instances and the deployment artifacts of the component
+func newComponentInstance(name string, origin Resource) ComponentInstance {
+	ci := ComponentInstance{
+		UniqueID:            uuid.NewV4().String(),
+		Name:                name,
+		InvariantName:       name,
+		NormalizedName:      normalizeComponentInstanceName(name),
+		CustomizationUUID:   uuid.NewV4().String(),
+		ComponentUID:        origin.UniqueID,
+		ComponentName:       origin.Name,
+		OriginType:          origin.ResourceType,
+		ComponentVersion:    origin.Version,
+		DeploymentArtifacts: map[string]Artifact{},
+		Properties:          []Property{},
+		Inputs:              []Input{},
+	}
+	for label, artifact := range origin.DeploymentArtifacts {
+		ci.DeploymentArtifacts[label] = artifact
+	}
+	ci.GroupInstances = vfModuleInstances(ci, origin)
+	return ci
+}
+
+// addInstanceProperties copies the properties and inputs of the origin
+// component to a new component instance of a resource
+func addInstanceProperties(r *Resource, ci ComponentInstance, origin Resource) {
+	properties := []Property{}
+	for _, p := range origin.Properties {
+		p.ParentUniqueID = ci.UniqueID
+		properties = append(properties, p)
+	}
+	inputs := []Input{}
+	for _, input := range origin.Inputs {
+		input.InstanceUniqueID = ci.UniqueID
+		inputs = append(inputs, input)
+	}
+	initResourceCollections(r)
+	r.ComponentInstancesProperties[ci.UniqueID] = properties
+	r.ComponentInstancesInputs[ci.UniqueID] = inputs
+	refreshComponentInstance(r, ci.UniqueID)
+}
+
+// refreshComponentInstance copies the current properties and inputs of a
+// component instance into it and gives it a new customization UUID, as SDC
+// does on every change of an instance
+func refreshComponentInstance(r *Resource, instanceID string) {
+	j := findComponentInstance(r.ComponentInstances, instanceID)
+	if j < 0 {
+		return
+	}
+	ci := &r.ComponentInstances[j]
+	ci.NormalizedName = normalizeComponentInstanceName(ci.Name)
+	ci.CustomizationUUID = uuid.NewV4().String()
+	ci.Properties = append([]Property{}, r.ComponentInstancesProperties[instanceID]...)
+	ci.Inputs = append([]Input{}, r.ComponentInstancesInputs[instanceID]...)
+}
+
+// addInstanceArtifact attaches the artifact described by an upload body to
+// the component instance j of a service, as a deployment artifact by default
+func addInstanceArtifact(r *Resource, j int, body ArtifactAdd) (Artifact, *SdcError) {
+	ci := &r.ComponentInstances[j]
+	if body.ArtifactGroupType == "" {
+		body.ArtifactGroupType = "DEPLOYMENT"
+	}
+	if sdcError := validateArtifactType(body.ArtifactType,
+		body.ArtifactGroupType, "RESOURCE_INSTANCE",
+		ci.OriginType, body.ArtifactName); sdcError != nil {
+		return Artifact{}, sdcError
+	}
+	artifact, sdcError := newArtifact(body, ci.UniqueID)
+	if sdcError != nil {
+		return Artifact{}, sdcError
+	}
+	if ci.DeploymentArtifacts == nil {
+		ci.DeploymentArtifacts = map[string]Artifact{}
+	}
+	ci.DeploymentArtifacts[artifact.ArtifactLabel] = artifact
+	refreshComponentInstance(r, ci.UniqueID)
+	return artifact, nil
+}
+
+// postComponentInstanceProperties updates the values of the properties of a
+// component instance
+func postComponentInstanceProperties(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	instanceID := c.Param("instanceID")
+	if findComponentInstance(resourceList[i].ComponentInstances, instanceID) < 0 {
+		return c.JSON(newSdcError("COMPONENT_INSTANCE_NOT_FOUND", instanceID))
+	}
+	updates, err := parsePropertiesBody(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	properties := resourceList[i].ComponentInstancesProperties[instanceID]
+	updated := []Property{}
+	for _, u := range updates {
+		j := findProperty(properties, u.Name)
+		if u.UniqueID != "" {
+			j = findProperty(properties, u.UniqueID)
+		}
+		if j < 0 {
+			return c.JSON(http.StatusNotFound, SdcError{
+				Message:   "Property " + u.Name + " not found.",
+				ErrorCode: "SVC4315",
+				Status:    "Not Found"})
+		}
+		p := properties[j]
+		p.Value = u.Value
+		if sdcError := validateProperty(p, modelTypes(resourceList[i].Model)); sdcError != nil {
+			return c.JSON(http.StatusBadRequest, sdcError)
+		}
+		updated = append(updated, p)
+	}
+	for _, p := range updated {
+		properties[findProperty(properties, p.Name)] = p
+	}
+	refreshComponentInstance(&resourceList[i], instanceID)
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, updated)
+}
+
+// ComponentInstanceUpdate is the body used to rename a component instance
+type ComponentInstanceUpdate struct {
+	Name string `json:"name"`
+}
+
+// postComponentInstance renames a component instance. Its invariant name,
+// used by the TOSCA templates of SDC, stays the one it was created with.
+func postComponentInstance(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	instanceID := c.Param("instanceID")
+	j := findComponentInstance(resourceList[i].ComponentInstances, instanceID)
+	if j < 0 {
+		return c.JSON(newSdcError("COMPONENT_INSTANCE_NOT_FOUND", instanceID))
+	}
+	update := new(ComponentInstanceUpdate)
+	if err := c.Bind(update); err != nil || update.Name == "" {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	for k, ci := range resourceList[i].ComponentInstances {
+		if k != j && ci.NormalizedName == normalizeComponentInstanceName(update.Name) {
+			return c.JSON(http.StatusConflict, SdcError{
+				Message:   "Component instance with " + update.Name + " name already exists.",
+				ErrorCode: "SVC4101",
+				Status:    "Exists"})
+		}
+	}
+	resourceList[i].ComponentInstances[j].Name = update.Name
+	refreshComponentInstance(&resourceList[i], instanceID)
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, resourceList[i].ComponentInstances[j])
+}
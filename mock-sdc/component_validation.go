@@ -0,0 +1,130 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Limits and formats of the fields of a component, as validated by SDC
+const (
+	componentNameMaxLength = 1024
+	tagMaxLength           = 1024
+	tagsMaxLength          = 1024
+	iconMaxLength          = 25
+)
+
+var componentNamePattern = regexp.MustCompile(`^[\w .\-:+]+$`)
+var contactIDPattern = regexp.MustCompile(`^([a-zA-Z]{2}[0-9]{3}[a-zA-Z0-9]|m[0-9]{6})$`)
+var iconPattern = regexp.MustCompile(`^[\w-]+$`)
+
+// componentKind is the way SDC names the type of a component in errors
+func componentKind(r Resource) string {
+	if r.ComponentType == "SERVICE" {
+		return "Service"
+	}
+	return "Resource"
+}
+
+func validateComponentName(r *Resource) (int, *SdcError) {
+	kind := componentKind(*r)
+	r.Name = strings.TrimSpace(r.Name)
+	if len(r.Name) > componentNameMaxLength {
+		return newSdcError("COMPONENT_NAME_EXCEEDS_LIMIT", kind, strconv.Itoa(componentNameMaxLength))
+	}
+	if !componentNamePattern.MatchString(r.Name) {
+		return newSdcError("INVALID_COMPONENT_NAME", kind)
+	}
+	for _, other := range resourceList {
+		if componentKind(other) == kind && other.Model == r.Model && strings.EqualFold(other.Name, r.Name) {
+			return newSdcError("COMPONENT_NAME_ALREADY_EXIST", kind, r.Name)
+		}
+	}
+	return 0, nil
+}
+
+// validateComponentTags checks the tags of a component: one of them is its
+// name, and they fit in the limits of SDC
+func validateComponentTags(r Resource) (int, *SdcError) {
+	if len(r.Tags) == 0 {
+		return newSdcError("COMPONENT_MISSING_TAGS")
+	}
+	length := 0
+	hasName := false
+	for _, tag := range r.Tags {
+		if len(tag) > tagMaxLength {
+			return newSdcError("COMPONENT_SINGLE_TAG_EXCEED_LIMIT", strconv.Itoa(tagMaxLength))
+		}
+		if !componentNamePattern.MatchString(tag) {
+			return newSdcError("INVALID_TAG")
+		}
+		length += len(tag)
+		hasName = hasName || tag == r.Name
+	}
+	if length+len(r.Tags)-1 > tagsMaxLength {
+		return newSdcError("COMPONENT_TAGS_EXCEED_LIMIT", strconv.Itoa(tagsMaxLength))
+	}
+	if !hasName {
+		return newSdcError("COMPONENT_INVALID_TAGS_NO_COMP_NAME")
+	}
+	return 0, nil
+}
+
+func validateComponentIcon(r Resource) (int, *SdcError) {
+	kind := componentKind(r)
+	if r.Icon == "" {
+		return newSdcError("COMPONENT_MISSING_ICON", kind)
+	}
+	if len(r.Icon) > iconMaxLength {
+		return newSdcError("COMPONENT_ICON_EXCEEDS_LIMIT", kind, strconv.Itoa(iconMaxLength))
+	}
+	if !iconPattern.MatchString(r.Icon) {
+		return newSdcError("COMPONENT_INVALID_ICON", kind)
+	}
+	return 0, nil
+}
+
+// validateComponent applies the validations of SDC to a new component, in
+// the order SDC does. It returns the first error with its HTTP status.
+func validateComponent(r *Resource) (int, *SdcError) {
+	kind := componentKind(*r)
+	if r.Model != "" && findModelIndex(r.Model) < 0 {
+		return newSdcError("MODEL_NOT_FOUND", r.Model)
+	}
+	if status, sdcError := validateComponentName(r); sdcError != nil {
+		return status, sdcError
+	}
+	if status, sdcError := validateComponentTags(*r); sdcError != nil {
+		return status, sdcError
+	}
+	if r.Category == "" && len(r.Categories) == 0 {
+		return newSdcError("COMPONENT_MISSING_CATEGORY", kind)
+	}
+	if r.ContactID == "" {
+		return newSdcError("COMPONENT_MISSING_CONTACT", kind)
+	}
+	if !contactIDPattern.MatchString(r.ContactID) {
+		return newSdcError("COMPONENT_INVALID_CONTACT", kind)
+	}
+	if status, sdcError := validateComponentIcon(*r); sdcError != nil {
+		return status, sdcError
+	}
+	if r.ComponentType != "SERVICE" && strings.TrimSpace(r.VendorName) == "" {
+		return newSdcError("MISSING_VENDOR_NAME")
+	}
+	return 0, nil
+}
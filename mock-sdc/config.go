@@ -0,0 +1,43 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"embed"
+	"os"
+	"path/filepath"
+)
+
+//go:embed config
+var defaultConfiguration embed.FS
+
+// readConfiguration returns the content of the file pointed by the envVar
+// environment variable, or the bundled default config/<name> if unset
+func readConfiguration(envVar string, name string) ([]byte, error) {
+	if path := os.Getenv(envVar); path != "" {
+		return os.ReadFile(path)
+	}
+	return defaultConfiguration.ReadFile("config/" + name)
+}
+
+// readConfigurationFile returns the content of the file name of the
+// directory pointed by the envVar environment variable, or of the bundled
+// default config/<dir> if unset
+func readConfigurationFile(envVar string, dir string, name string) ([]byte, error) {
+	if path := os.Getenv(envVar); path != "" {
+		return os.ReadFile(filepath.Join(path, name))
+	}
+	return defaultConfiguration.ReadFile("config/" + dir + "/" + name)
+}
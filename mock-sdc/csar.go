@@ -0,0 +1,463 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ToscaEntrySchema is the entry_schema of a TOSCA list or map
+type ToscaEntrySchema struct {
+	Type string `yaml:"type"`
+}
+
+// ToscaParameter describes a TOSCA input or property definition
+type ToscaParameter struct {
+	Type        string            `yaml:"type"`
+	Description string            `yaml:"description,omitempty"`
+	Default     interface{}       `yaml:"default,omitempty"`
+	Required    *bool             `yaml:"required,omitempty"`
+	EntrySchema *ToscaEntrySchema `yaml:"entry_schema,omitempty"`
+}
+
+// ToscaNodeTemplate describes a TOSCA node template
+type ToscaNodeTemplate struct {
+	Type         string                   `yaml:"type"`
+	Metadata     map[string]string        `yaml:"metadata,omitempty"`
+	Properties   map[string]interface{}   `yaml:"properties,omitempty"`
+	Requirements []map[string]interface{} `yaml:"requirements,omitempty"`
+}
+
+// ToscaGroup describes a TOSCA group
+type ToscaGroup struct {
+	Type       string                 `yaml:"type"`
+	Metadata   map[string]string      `yaml:"metadata,omitempty"`
+	Members    []string               `yaml:"members,omitempty"`
+	Properties map[string]interface{} `yaml:"properties,omitempty"`
+}
+
+// ToscaSubstitutionMappings describes what a topology template substitutes
+type ToscaSubstitutionMappings struct {
+	NodeType string `yaml:"node_type,omitempty"`
+}
+
+// ToscaTopologyTemplate describes a TOSCA topology template
+type ToscaTopologyTemplate struct {
+	Inputs               map[string]ToscaParameter    `yaml:"inputs,omitempty"`
+	NodeTemplates        map[string]ToscaNodeTemplate `yaml:"node_templates,omitempty"`
+	Groups               map[string]ToscaGroup        `yaml:"groups,omitempty"`
+	SubstitutionMappings ToscaSubstitutionMappings    `yaml:"substitution_mappings,omitempty"`
+}
+
+// ToscaTemplate describes a TOSCA service template file
+type ToscaTemplate struct {
+	ToscaDefinitionsVersion string                `yaml:"tosca_definitions_version"`
+	Metadata                map[string]string     `yaml:"metadata,omitempty"`
+	Description             string                `yaml:"description,omitempty"`
+	Imports                 []interface{}         `yaml:"imports,omitempty"`
+	TopologyTemplate        ToscaTopologyTemplate `yaml:"topology_template,omitempty"`
+}
+
+// Csar is a parsed TOSCA Cloud Service ARchive
+type Csar struct {
+	Payload          []byte
+	Meta             map[string]string
+	EntryDefinitions string
+	MainTemplate     ToscaTemplate
+	Files            map[string][]byte
+}
+
+// CsarArtifact is a file found under Artifacts/ in a CSAR
+type CsarArtifact struct {
+	Path              string
+	ArtifactType      string
+	ArtifactGroupType string
+}
+
+// parseToscaMeta reads the "Key: value" lines of TOSCA-Metadata/TOSCA.meta
+func parseToscaMeta(data []byte) map[string]string {
+	meta := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if found {
+			meta[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return meta
+}
+
+var errNotZip = errors.New("not a zip archive")
+
+// writeZip returns a zip archive of files by path
+func writeZip(files map[string][]byte) []byte {
+	buffer := new(bytes.Buffer)
+	archive := zip.NewWriter(buffer)
+	for _, name := range sortedKeys(files) {
+		if w, err := archive.Create(name); err == nil {
+			w.Write(files[name])
+		}
+	}
+	archive.Close()
+	return buffer.Bytes()
+}
+
+// readZip returns the content of the files of a zip archive by path
+func readZip(payload []byte) (map[string][]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(payload), int64(len(payload)))
+	if err != nil {
+		return nil, errNotZip
+	}
+	files := map[string][]byte{}
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[f.Name] = data
+	}
+	return files, nil
+}
+
+// parseCsar opens a CSAR and reads its main service template, either the
+// Entry-Definitions of TOSCA.meta or the only YAML file at the root
+func parseCsar(payload []byte) (*Csar, error) {
+	files, err := readZip(payload)
+	if err != nil {
+		return nil, err
+	}
+	csar := &Csar{Payload: payload, Meta: map[string]string{}, Files: files}
+	if meta, found := csar.Files["TOSCA-Metadata/TOSCA.meta"]; found {
+		csar.Meta = parseToscaMeta(meta)
+		csar.EntryDefinitions = csar.Meta["Entry-Definitions"]
+	} else {
+		for name := range csar.Files {
+			if !strings.Contains(name, "/") &&
+				(strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+				if csar.EntryDefinitions != "" {
+					return nil, errors.New("no TOSCA.meta and several YAML files at the root")
+				}
+				csar.EntryDefinitions = name
+			}
+		}
+	}
+	if csar.EntryDefinitions == "" {
+		return nil, errors.New("no Entry-Definitions found")
+	}
+	template, found := csar.Files[csar.EntryDefinitions]
+	if !found {
+		return nil, errors.New("Entry-Definitions " + csar.EntryDefinitions + " not found in the archive")
+	}
+	if err := yaml.Unmarshal(template, &csar.MainTemplate); err != nil {
+		return nil, errors.New("invalid main template: " + err.Error())
+	}
+	if csar.MainTemplate.ToscaDefinitionsVersion == "" {
+		return nil, errors.New("main template has no tosca_definitions_version")
+	}
+	return csar, nil
+}
+
+// csarArtifacts lists the files found under Artifacts/. The group and the
+// type are read from the Artifacts/<Deployment|Informational>/<TYPE>/ layout
+// used by SDC, anything else being an informational OTHER artifact.
+func (csar *Csar) csarArtifacts() []CsarArtifact {
+	artifacts := []CsarArtifact{}
+	for name := range csar.Files {
+		if !strings.HasPrefix(name, "Artifacts/") {
+			continue
+		}
+		artifact := CsarArtifact{Path: name, ArtifactType: "OTHER", ArtifactGroupType: "INFORMATIONAL"}
+		parts := strings.Split(name, "/")
+		if len(parts) >= 4 {
+			switch strings.ToLower(parts[1]) {
+			case "deployment":
+				artifact.ArtifactGroupType = "DEPLOYMENT"
+			case "informational":
+				artifact.ArtifactGroupType = "INFORMATIONAL"
+			}
+			if _, found := findArtifactType(parts[2]); found {
+				artifact.ArtifactType = parts[2]
+			}
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Path < artifacts[j].Path })
+	return artifacts
+}
+
+// metadataValue returns the first non empty value among metadata keys
+func metadataValue(metadata map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := metadata[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// toscaValue turns a TOSCA value into the string stored in SDC properties:
+// strings are kept as is, anything else is JSON encoded
+func toscaValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if text, ok := value.(string); ok {
+		return text
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// sortedKeys returns the keys of a map in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toscaName turns a component name into the last part of a TOSCA type name,
+// "vFW VF 0" becoming "VfwVf0"
+func toscaName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+	}
+	return b.String()
+}
+
+// toscaResourceName is the node type SDC generates for a component
+func toscaResourceName(componentType string, resourceType string, name string) string {
+	if componentType == "SERVICE" {
+		return "org.openecomp.service." + toscaName(name)
+	}
+	return "org.openecomp.resource." + strings.ToLower(resourceType) + "." + toscaName(name)
+}
+
+// componentToscaResourceName is the node type of a component: the one it
+// was defined with for normative types, the generated one otherwise
+func componentToscaResourceName(r Resource) string {
+	if r.ToscaResourceName != "" {
+		return r.ToscaResourceName
+	}
+	return toscaResourceName(r.ComponentType, r.ResourceType, r.Name)
+}
+
+// toscaPropertyValue turns the string stored in SDC properties back into a
+// TOSCA value, the reverse of toscaValue
+func toscaPropertyValue(value string, typeName string) interface{} {
+	if value == "" {
+		return nil
+	}
+	if typeName == "string" && !isGetFunction(value) {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// toscaProperties returns the values of the properties which have one
+func toscaProperties(properties []Property) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, p := range properties {
+		if value := toscaPropertyValue(p.Value, p.Type); value != nil {
+			values[p.Name] = value
+		}
+	}
+	return values
+}
+
+// toscaTemplateName is the name SDC gives to the main template of a component
+func toscaTemplateName(r Resource) string {
+	prefix := "resource-"
+	if r.ComponentType == "SERVICE" {
+		prefix = "service-"
+	}
+	return prefix + toscaName(r.Name) + "-template.yml"
+}
+
+// buildToscaTemplate generates the main service template of a component
+func buildToscaTemplate(r Resource) ToscaTemplate {
+	metadata := map[string]string{
+		"invariantUUID": r.InvariantID,
+		"UUID":          r.ID,
+		"name":          r.Name,
+		"description":   r.Description,
+		"category":      r.Category,
+		"subcategory":   r.SubCategory,
+	}
+	if r.ComponentType == "SERVICE" {
+		metadata["type"] = "Service"
+		delete(metadata, "subcategory")
+	} else {
+		metadata["type"] = r.ResourceType
+		metadata["resourceVendor"] = r.VendorName
+		metadata["resourceVendorRelease"] = r.VendorRelease
+	}
+	template := ToscaTemplate{
+		ToscaDefinitionsVersion: "tosca_simple_yaml_1_1",
+		Metadata:                metadata,
+		TopologyTemplate: ToscaTopologyTemplate{
+			Inputs:        map[string]ToscaParameter{},
+			NodeTemplates: map[string]ToscaNodeTemplate{},
+			SubstitutionMappings: ToscaSubstitutionMappings{
+				NodeType: componentToscaResourceName(r),
+			},
+		},
+	}
+	for _, input := range r.Inputs {
+		parameter := ToscaParameter{
+			Type:        input.Type,
+			Description: input.Description,
+			Default:     toscaPropertyValue(input.Value, input.Type),
+		}
+		if input.Schema != nil {
+			parameter.EntrySchema = &ToscaEntrySchema{Type: input.Schema.Property.Type}
+		}
+		template.TopologyTemplate.Inputs[input.Name] = parameter
+	}
+	for _, ci := range r.ComponentInstances {
+		nodeType := toscaResourceName("RESOURCE", ci.OriginType, ci.ComponentName)
+		if origin, found := instanceOrigin(ci); found {
+			nodeType = componentToscaResourceName(origin)
+		}
+		node := ToscaNodeTemplate{
+			Type: nodeType,
+			Metadata: map[string]string{
+				"name":              ci.ComponentName,
+				"type":              ci.OriginType,
+				"version":           ci.ComponentVersion,
+				"customizationUUID": ci.CustomizationUUID,
+			},
+			Properties: toscaProperties(r.ComponentInstancesProperties[ci.UniqueID]),
+		}
+		if origin, found := instanceOrigin(ci); found {
+			node.Metadata["UUID"] = origin.ID
+			node.Metadata["invariantUUID"] = origin.InvariantID
+		}
+		for _, relation := range r.ComponentInstancesRelations {
+			j := findComponentInstance(r.ComponentInstances, relation.ToNode)
+			if relation.FromNode != ci.UniqueID || j < 0 {
+				continue
+			}
+			for _, relationship := range relation.Relationships {
+				node.Requirements = append(node.Requirements, map[string]interface{}{
+					relationship.Relation.Requirement: map[string]string{
+						"capability":   relationship.Relation.Capability,
+						"node":         r.ComponentInstances[j].Name,
+						"relationship": relationship.Relation.Relationship.Type,
+					},
+				})
+			}
+		}
+		template.TopologyTemplate.NodeTemplates[ci.Name] = node
+		for _, g := range ci.GroupInstances {
+			group := ToscaGroup{
+				Type: g.Type,
+				Metadata: map[string]string{
+					"vfModuleModelName":              g.GroupName,
+					"vfModuleModelInvariantUUID":     g.InvariantUUID,
+					"vfModuleModelUUID":              g.GroupUUID,
+					"vfModuleModelVersion":           g.Version,
+					"vfModuleModelCustomizationUUID": g.CustomizationUUID,
+				},
+				Properties: toscaProperties(g.Properties),
+			}
+			if template.TopologyTemplate.Groups == nil {
+				template.TopologyTemplate.Groups = map[string]ToscaGroup{}
+			}
+			template.TopologyTemplate.Groups[g.Name] = group
+		}
+	}
+	for _, g := range r.Groups {
+		group := ToscaGroup{
+			Type: g.Type,
+			Metadata: map[string]string{
+				"vfModuleModelName":          g.Name,
+				"vfModuleModelInvariantUUID": g.InvariantUUID,
+				"vfModuleModelUUID":          g.GroupUUID,
+				"vfModuleModelVersion":       g.Version,
+			},
+			Members:    sortedKeys(g.Members),
+			Properties: toscaProperties(g.Properties),
+		}
+		if template.TopologyTemplate.Groups == nil {
+			template.TopologyTemplate.Groups = map[string]ToscaGroup{}
+		}
+		template.TopologyTemplate.Groups[g.Name] = group
+	}
+	return template
+}
+
+// buildCsar generates the TOSCA CSAR of a component: its main template and
+// its deployment and informational artifacts
+func buildCsar(r Resource) []byte {
+	templateName := "Definitions/" + toscaTemplateName(r)
+	template := new(bytes.Buffer)
+	encoder := yaml.NewEncoder(template)
+	encoder.SetIndent(2)
+	encoder.Encode(buildToscaTemplate(r))
+	buffer := new(bytes.Buffer)
+	archive := zip.NewWriter(buffer)
+	add := func(name string, data []byte) {
+		w, err := archive.Create(name)
+		if err == nil {
+			w.Write(data)
+		}
+	}
+	add("TOSCA-Metadata/TOSCA.meta", []byte("TOSCA-Meta-File-Version: 1.0\n"+
+		"CSAR-Version: 1.1\n"+
+		"Created-By: Carlos Santana\n"+
+		"Entry-Definitions: "+templateName+"\n"))
+	add(templateName, template.Bytes())
+	for _, group := range []struct {
+		dir       string
+		artifacts map[string]Artifact
+	}{{"Deployment", r.DeploymentArtifacts}, {"Informational", r.Artifacts}} {
+		for _, label := range sortedKeys(group.artifacts) {
+			a := group.artifacts[label]
+			add("Artifacts/"+group.dir+"/"+a.ArtifactType+"/"+a.ArtifactName, a.Payload)
+		}
+	}
+	archive.Close()
+	return buffer.Bytes()
+}
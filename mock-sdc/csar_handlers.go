@@ -0,0 +1,494 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/labstack/gommon/log"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeCsarPayload reads the base64 payloadData of a creation request
+func decodeCsarPayload(payloadData string, payloadName string) (*Csar, *SdcError) {
+	invalid := func(reason string) *SdcError {
+		return &SdcError{
+			Message:   "CSAR " + payloadName + " is invalid. Reason - " + reason + ".",
+			ErrorCode: "SVC4587",
+			Status:    "Invalid Content"}
+	}
+	payload, err := base64.StdEncoding.DecodeString(payloadData)
+	if err != nil {
+		return nil, invalid("base64 encoding expected")
+	}
+	csar, err := parseCsar(payload)
+	if err != nil {
+		return nil, invalid(err.Error())
+	}
+	return csar, nil
+}
+
+// toscaSchema converts a TOSCA entry_schema to an SDC property schema
+func toscaSchema(entrySchema *ToscaEntrySchema) *PropertySchema {
+	if entrySchema == nil {
+		return nil
+	}
+	return &PropertySchema{Property: SchemaProperty{Type: entrySchema.Type}}
+}
+
+// toscaValueType guesses the type of a node template property value when the
+// node type it comes from is not in the catalog
+func toscaValueType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case int, int64:
+		return "integer"
+	case float64:
+		return "float"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		if isGetFunction(toscaValue(value)) {
+			return "string"
+		}
+		return "map"
+	}
+	return "string"
+}
+
+// findCsarOrigin looks the component a node template is an instance of up in
+// the catalog, first from its metadata then from its node type, among the
+// components of the model of the imported component
+func findCsarOrigin(template ToscaNodeTemplate, model string) int {
+	for i, r := range resourceList {
+		if template.Metadata["UUID"] != "" && r.ID == template.Metadata["UUID"] {
+			return i
+		}
+	}
+	for i, r := range resourceList {
+		if template.Metadata["invariantUUID"] != "" && r.InvariantID == template.Metadata["invariantUUID"] {
+			return i
+		}
+	}
+	name := metadataValue(template.Metadata, "name")
+	if name == "" {
+		name = template.Type[strings.LastIndex(template.Type, ".")+1:]
+	}
+	for i, r := range resourceList {
+		if r.Name == name && r.ComponentType != "SERVICE" && r.Model == model {
+			return i
+		}
+	}
+	return -1
+}
+
+// isServiceCsar tells whether the main template of a CSAR is a service one
+func isServiceCsar(csar *Csar) bool {
+	return strings.EqualFold(csar.MainTemplate.Metadata["type"], "Service")
+}
+
+// nestedCsar returns the template of a CSAR that substitutes a node type, as
+// a CSAR of its own. SDC puts one in service CSARs for each used resource.
+func (csar *Csar) nestedCsar(nodeType string) *Csar {
+	for _, name := range sortedKeys(csar.Files) {
+		if name == csar.EntryDefinitions ||
+			!(strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".yaml")) {
+			continue
+		}
+		template := ToscaTemplate{}
+		if err := yaml.Unmarshal(csar.Files[name], &template); err != nil ||
+			template.TopologyTemplate.SubstitutionMappings.NodeType != nodeType {
+			continue
+		}
+		return &Csar{
+			Meta:             csar.Meta,
+			EntryDefinitions: name,
+			MainTemplate:     template,
+			Files:            map[string][]byte{name: csar.Files[name]},
+		}
+	}
+	return nil
+}
+
+// importCsarResources creates the certified resources used by the node
+// templates of a service CSAR of the model that are not yet in the catalog
+func importCsarResources(csar *Csar, model string) {
+	topology := csar.MainTemplate.TopologyTemplate
+	for _, name := range sortedKeys(topology.NodeTemplates) {
+		template := topology.NodeTemplates[name]
+		if findCsarOrigin(template, model) >= 0 {
+			continue
+		}
+		nested := csar.nestedCsar(template.Type)
+		if nested == nil {
+			nested = &Csar{Files: map[string][]byte{}}
+		}
+		for key, value := range template.Metadata {
+			if _, found := nested.MainTemplate.Metadata[key]; !found {
+				if nested.MainTemplate.Metadata == nil {
+					nested.MainTemplate.Metadata = map[string]string{}
+				}
+				nested.MainTemplate.Metadata[key] = value
+			}
+		}
+		metadata := nested.MainTemplate.Metadata
+		resource := Resource{ComponentType: "RESOURCE", Model: model}
+		importCsarMetadata(&resource, nested)
+		if resource.Name == "" {
+			resource.Name = template.Type[strings.LastIndex(template.Type, ".")+1:]
+		}
+		if validateResourceCategory(&resource) != nil {
+			resource.Categories = nil
+		}
+		resource.ID = metadataValue(metadata, "UUID")
+		if resource.ID == "" {
+			resource.ID = uuid.NewV4().String()
+		}
+		resource.InvariantID = metadataValue(metadata, "invariantUUID")
+		if resource.InvariantID == "" {
+			resource.InvariantID = uuid.NewV4().String()
+		}
+		resource.UniqueID = uuid.NewV4().String()
+		resource.Version = metadataValue(metadata, "version")
+		if resource.Version == "" {
+			resource.Version = "1.0"
+		}
+		resource.LifecycleState = "CERTIFIED"
+		resource.LastUpdaterUserID = "jh0003"
+		resource.ToscaModelURL = "/sdc/v1/catalog/resources/" + resource.ID + "/toscaModel"
+		importCsarTopology(&resource, nested, "")
+		resourceList = append(resourceList, resource)
+	}
+}
+
+// newToscaGroup converts a TOSCA group of a component topology template
+func newToscaGroup(r Resource, name string, g ToscaGroup) Group {
+	group := Group{
+		UniqueID:          r.UniqueID + "." + name,
+		Name:              name,
+		Type:              g.Type,
+		Version:           metadataValue(g.Metadata, "vfModuleModelVersion", "version"),
+		GroupUUID:         metadataValue(g.Metadata, "vfModuleModelUUID", "UUID"),
+		InvariantUUID:     metadataValue(g.Metadata, "vfModuleModelInvariantUUID", "invariantUUID"),
+		CustomizationUUID: uuid.NewV4().String(),
+		Description:       metadataValue(g.Metadata, "description"),
+		Members:           map[string]string{},
+		Artifacts:         []string{},
+		ArtifactsUUID:     []string{},
+		Properties:        []Property{},
+	}
+	if group.Version == "" {
+		group.Version = "1"
+	}
+	if group.GroupUUID == "" {
+		group.GroupUUID = uuid.NewV4().String()
+	}
+	if group.InvariantUUID == "" {
+		group.InvariantUUID = uuid.NewV4().String()
+	}
+	for _, member := range g.Members {
+		for _, ci := range r.ComponentInstances {
+			if ci.Name == member {
+				group.Members[member] = ci.UniqueID
+			}
+		}
+	}
+	for _, propertyName := range sortedKeys(g.Properties) {
+		value := g.Properties[propertyName]
+		group.Properties = append(group.Properties, Property{
+			Name:           propertyName,
+			Value:          toscaValue(value),
+			Type:           toscaValueType(value),
+			UniqueID:       group.UniqueID + "." + propertyName,
+			ParentUniqueID: group.UniqueID,
+		})
+	}
+	return group
+}
+
+// importCsarMetadata fills a new component from the metadata of a CSAR.
+// Values already given in the creation request are kept.
+func importCsarMetadata(resource *Resource, csar *Csar) {
+	metadata := csar.MainTemplate.Metadata
+	if resource.Name == "" {
+		resource.Name = metadataValue(metadata, "name", "template_name")
+	}
+	if resource.Name == "" {
+		nodeType := csar.MainTemplate.TopologyTemplate.SubstitutionMappings.NodeType
+		resource.Name = nodeType[strings.LastIndex(nodeType, ".")+1:]
+	}
+	if resource.Description == "" {
+		resource.Description = metadataValue(metadata, "description")
+	}
+	if resource.Description == "" {
+		resource.Description = csar.MainTemplate.Description
+	}
+	if resource.ResourceType == "" && resource.ComponentType != "SERVICE" {
+		resource.ResourceType = metadataValue(metadata, "type")
+		if resource.ResourceType == "" {
+			resource.ResourceType = "VF"
+		}
+	}
+	if resource.VendorName == "" {
+		resource.VendorName = metadataValue(metadata, "resourceVendor", "vendor", "template_author")
+	}
+	if resource.VendorName == "" {
+		resource.VendorName = csar.Meta["Created-By"]
+	}
+	if resource.VendorRelease == "" {
+		resource.VendorRelease = metadataValue(metadata, "resourceVendorRelease", "vendorRelease", "template_version")
+	}
+	if resource.Category == "" && len(resource.Categories) == 0 {
+		resource.Category = metadataValue(metadata, "category")
+		resource.SubCategory = metadataValue(metadata, "subcategory")
+	}
+	if len(resource.Tags) == 0 {
+		resource.Tags = []string{resource.Name}
+	}
+	if resource.Icon == "" {
+		resource.Icon = "defaulticon"
+	}
+}
+
+// importCsarTopology fills a new component, whose unique id is already set,
+// from the topology template and the artifacts of a CSAR
+func importCsarTopology(resource *Resource, csar *Csar, payloadName string) {
+	initResourceCollections(resource)
+	topology := csar.MainTemplate.TopologyTemplate
+	resource.CsarUUID = uuid.NewV4().String()
+	resource.CsarVersion = "1.0"
+
+	for _, name := range sortedKeys(topology.Inputs) {
+		parameter := topology.Inputs[name]
+		resource.Inputs = append(resource.Inputs, Input{
+			Name:        name,
+			Value:       toscaValue(parameter.Default),
+			Type:        parameter.Type,
+			UniqueID:    resource.UniqueID + "." + name,
+			Description: parameter.Description,
+			Schema:      toscaSchema(parameter.EntrySchema),
+		})
+	}
+
+	for _, name := range sortedKeys(topology.NodeTemplates) {
+		template := topology.NodeTemplates[name]
+		origin := Resource{}
+		if o := findCsarOrigin(template, resource.Model); o >= 0 {
+			origin = resourceList[o]
+		}
+		ci := newComponentInstance(name, origin)
+		ci.ComponentName = metadataValue(template.Metadata, "name")
+		if ci.ComponentName == "" {
+			ci.ComponentName = origin.Name
+		}
+		if ci.ComponentName == "" {
+			ci.ComponentName = template.Type[strings.LastIndex(template.Type, ".")+1:]
+		}
+		ci.OriginType = metadataValue(template.Metadata, "type")
+		if ci.OriginType == "" {
+			ci.OriginType = origin.ResourceType
+		}
+		if ci.OriginType == "" {
+			ci.OriginType = "VFC"
+		}
+		ci.ComponentVersion = metadataValue(template.Metadata, "version")
+		if ci.ComponentVersion == "" {
+			ci.ComponentVersion = origin.Version
+		}
+		if ci.ComponentVersion == "" {
+			ci.ComponentVersion = "1.0"
+		}
+		resource.ComponentInstances = append(resource.ComponentInstances, ci)
+		addInstanceProperties(resource, ci, origin)
+		properties := resource.ComponentInstancesProperties[ci.UniqueID]
+		for _, propertyName := range sortedKeys(template.Properties) {
+			value := template.Properties[propertyName]
+			if j := findProperty(properties, propertyName); j >= 0 {
+				properties[j].Value = toscaValue(value)
+				continue
+			}
+			properties = append(properties, Property{
+				Name:           propertyName,
+				Value:          toscaValue(value),
+				Type:           toscaValueType(value),
+				UniqueID:       ci.UniqueID + "." + propertyName,
+				ParentUniqueID: ci.UniqueID,
+			})
+		}
+		resource.ComponentInstancesProperties[ci.UniqueID] = properties
+		refreshComponentInstance(resource, ci.UniqueID)
+		if customizationUUID := metadataValue(template.Metadata, "customizationUUID"); customizationUUID != "" {
+			resource.ComponentInstances[len(resource.ComponentInstances)-1].CustomizationUUID = customizationUUID
+		}
+	}
+
+	for _, name := range sortedKeys(topology.Groups) {
+		resource.Groups = append(resource.Groups, newToscaGroup(*resource, name, topology.Groups[name]))
+	}
+
+	for _, a := range csar.csarArtifacts() {
+		artifact, sdcError := newArtifactFromPayload(ArtifactAdd{
+			ArtifactName:      path.Base(a.Path),
+			ArtifactLabel:     path.Base(a.Path),
+			ArtifactType:      a.ArtifactType,
+			ArtifactGroupType: a.ArtifactGroupType,
+			Description:       "Imported from " + a.Path,
+		}, csar.Files[a.Path], resource.UniqueID)
+		if sdcError != nil {
+			continue
+		}
+		(*artifactGroup(resource, a.ArtifactGroupType))[artifact.ArtifactLabel] = artifact
+	}
+
+	if csar.EntryDefinitions != "" {
+		template, _ := newArtifactFromPayload(ArtifactAdd{
+			ArtifactName:      path.Base(csar.EntryDefinitions),
+			ArtifactLabel:     "assettoscatemplate",
+			ArtifactType:      "TOSCA_TEMPLATE",
+			ArtifactGroupType: "TOSCA",
+			Description:       "TOSCA representation of the asset",
+		}, csar.Files[csar.EntryDefinitions], resource.UniqueID)
+		resource.ToscaArtifacts[template.ArtifactLabel] = template
+	}
+	if len(csar.Payload) != 0 {
+		if payloadName == "" {
+			payloadName = resource.Name + ".csar"
+		}
+		archive, _ := newArtifactFromPayload(ArtifactAdd{
+			ArtifactName:      payloadName,
+			ArtifactLabel:     "assettoscacsar",
+			ArtifactType:      "TOSCA_CSAR",
+			ArtifactGroupType: "TOSCA",
+			Description:       "TOSCA definition package of the asset",
+		}, csar.Payload, resource.UniqueID)
+		resource.ToscaArtifacts[archive.ArtifactLabel] = archive
+	}
+}
+
+// catalogCollection is the external API collection a component belongs to
+func catalogCollection(r Resource) string {
+	if r.ComponentType == "SERVICE" {
+		return "services"
+	}
+	return "resources"
+}
+
+// importCatalogCsar registers a CSAR as a certified and distributed component
+func importCatalogCsar(name string, csar *Csar) error {
+	resource := Resource{ComponentType: "RESOURCE"}
+	if isServiceCsar(csar) {
+		resource.ComponentType = "SERVICE"
+	}
+	importCsarMetadata(&resource, csar)
+	if sdcError := validateResourceCategory(&resource); sdcError != nil {
+		return errors.New(name + ": " + sdcError.Message)
+	}
+	resource.ID = metadataValue(csar.MainTemplate.Metadata, "UUID")
+	if resource.ID != "" && findResourceUUIDIndex(resource.ID) >= 0 {
+		return errors.New(name + ": a component with UUID " + resource.ID + " is already in the catalog")
+	}
+	if resource.ID == "" {
+		resource.ID = uuid.NewV4().String()
+	}
+	if resource.ComponentType == "SERVICE" {
+		importCsarResources(csar, resource.Model)
+	}
+	resource.InvariantID = metadataValue(csar.MainTemplate.Metadata, "invariantUUID")
+	if resource.InvariantID == "" {
+		resource.InvariantID = uuid.NewV4().String()
+	}
+	resource.UniqueID = uuid.NewV4().String()
+	resource.Version = "1.0"
+	resource.LifecycleState = "CERTIFIED"
+	resource.DistributionStatus = "DISTRIBUTED"
+	resource.LastUpdaterUserID = "jh0003"
+	resource.ToscaModelURL = "/sdc/v1/catalog/" + catalogCollection(resource) + "/" + resource.ID + "/toscaModel"
+	importCsarTopology(&resource, csar, name)
+	resourceList = append(resourceList, resource)
+	return nil
+}
+
+// loadCsarDirectory registers all the CSARs of the directory pointed by
+// CSAR_DIR in the catalog, resources first so that services can use them.
+// The CSARs that cannot be read or imported are logged and skipped.
+func loadCsarDirectory() error {
+	dir := os.Getenv("CSAR_DIR")
+	if dir == "" {
+		return nil
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*.csar"))
+	if err != nil {
+		return err
+	}
+	services := map[string]*Csar{}
+	for _, name := range names {
+		payload, err := os.ReadFile(name)
+		if err != nil {
+			log.Warnf("skipping %v", err)
+			continue
+		}
+		csar, err := parseCsar(payload)
+		if err != nil {
+			log.Warnf("skipping %s: %v", name, err)
+			continue
+		}
+		if isServiceCsar(csar) {
+			services[name] = csar
+			continue
+		}
+		if err := importCatalogCsar(filepath.Base(name), csar); err != nil {
+			log.Warnf("skipping %v", err)
+		}
+	}
+	for _, name := range sortedKeys(services) {
+		if err := importCatalogCsar(filepath.Base(name), services[name]); err != nil {
+			log.Warnf("skipping %v", err)
+		}
+	}
+	return nil
+}
+
+// postServiceFromCsar creates a service from a service CSAR, along with the
+// resources it uses that are not yet in the catalog
+func postServiceFromCsar(c echo.Context) error {
+	user, ok, err := authorizeUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return err
+	}
+	resource := new(Resource)
+	if err := c.Bind(resource); err != nil {
+		return err
+	}
+	if resource.PayloadData == "" {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Missing CSAR payload.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	resource.ComponentType = "SERVICE"
+	if status, sdcError := registerComponent(resource, user); sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	return c.JSON(http.StatusCreated, resource)
+}
@@ -0,0 +1,211 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"container/list"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+// defaultDistributionEnv is the environment of the distributions activated
+// without one in the path
+const defaultDistributionEnv = "PROD"
+
+// RegistrationRequest is the body of the registration of a distribution
+// client
+type RegistrationRequest struct {
+	APIPublicKey                    string   `json:"apiPublicKey"`
+	DistrEnvName                    string   `json:"distrEnvName"`
+	IsConsumerToSdcDistrStatusTopic bool     `json:"isConsumerToSdcDistrStatusTopic"`
+	DistEnvEndPoints                []string `json:"distEnvEndPoints"`
+}
+
+// DistributionConsumer is a distribution client registered for an
+// environment
+type DistributionConsumer struct {
+	ConsumerID   string
+	APIPublicKey string
+	EnvName      string
+}
+
+// Distribution is an activation of the distribution of a service in an
+// environment, with the statuses of the consumers it was notified to
+type Distribution struct {
+	ID        string
+	EnvName   string
+	UserID    string
+	Timestamp int64
+	Statuses  []DistributionStatus
+}
+
+var distributionConsumers []DistributionConsumer
+
+func notificationTopic(env string) string {
+	return "SDC-DISTR-NOTIF-TOPIC-" + env
+}
+
+func statusTopic(env string) string {
+	return "SDC-DISTR-STATUS-TOPIC-" + env
+}
+
+func findDistributionConsumerIndex(consumerID string) int {
+	for i, consumer := range distributionConsumers {
+		if consumer.ConsumerID == consumerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// distributionConsumerID returns the consumer id sent in the
+// X-ECOMP-InstanceID header, or writes the error response
+func distributionConsumerID(c echo.Context) (string, error) {
+	consumerID := c.Request().Header.Get("X-ECOMP-InstanceID")
+	if consumerID == "" {
+		return "", c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Error: Missing 'X-ECOMP-InstanceID' HTTP header.",
+			ErrorCode: "POL5001",
+			Status:    "Missing Information"})
+	}
+	return consumerID, nil
+}
+
+func registerForDistribution(c echo.Context) error {
+	consumerID, err := distributionConsumerID(c)
+	if consumerID == "" {
+		return err
+	}
+	request := new(RegistrationRequest)
+	if err := c.Bind(request); err != nil || strings.TrimSpace(request.DistrEnvName) == "" {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	consumer := DistributionConsumer{
+		ConsumerID:   consumerID,
+		APIPublicKey: request.APIPublicKey,
+		EnvName:      strings.TrimSpace(request.DistrEnvName),
+	}
+	if i := findDistributionConsumerIndex(consumerID); i >= 0 {
+		distributionConsumers[i] = consumer
+	} else {
+		distributionConsumers = append(distributionConsumers, consumer)
+	}
+	distributionRegistration := map[string]string{
+		"distrNotificationTopicName": notificationTopic(consumer.EnvName),
+		"distrStatusTopicName":       statusTopic(consumer.EnvName),
+	}
+	return c.JSON(http.StatusOK, distributionRegistration)
+}
+
+func unRegisterForDistribution(c echo.Context) error {
+	consumerID, err := distributionConsumerID(c)
+	if consumerID == "" {
+		return err
+	}
+	if i := findDistributionConsumerIndex(consumerID); i >= 0 {
+		distributionConsumers = append(distributionConsumers[:i], distributionConsumers[i+1:]...)
+	}
+	return c.JSON(http.StatusOK, list.New())
+}
+
+// distributionKafkaData returns the topics of the environment the consumer
+// registered for, or the default ones for an unknown consumer
+func distributionKafkaData(c echo.Context) error {
+	kafkaData := map[string]string{
+		"kafkaBootStrapServer":       "localhost:43219",
+		"distrNotificationTopicName": "SDC-DIST-NOTIF-TOPIC",
+		"distrStatusTopicName":       "SDC-DIST-STATUS-TOPIC",
+	}
+	if i := findDistributionConsumerIndex(c.Request().Header.Get("X-ECOMP-InstanceID")); i >= 0 {
+		kafkaData["distrNotificationTopicName"] = notificationTopic(distributionConsumers[i].EnvName)
+		kafkaData["distrStatusTopicName"] = statusTopic(distributionConsumers[i].EnvName)
+	}
+	return c.JSON(http.StatusOK, kafkaData)
+}
+
+// distributedArtifactURLs lists the URLs of the deployment artifacts of a
+// service and of its instances, the way consumers download them
+func distributedArtifactURLs(r Resource) []string {
+	baseURL := "/sdc/v1/catalog/services/" + r.Name + "/" + r.Version
+	urls := []string{}
+	for _, label := range sortedKeys(r.DeploymentArtifacts) {
+		urls = append(urls, baseURL+"/artifacts/"+r.DeploymentArtifacts[label].ArtifactName)
+	}
+	for _, ci := range r.ComponentInstances {
+		for _, label := range sortedKeys(ci.DeploymentArtifacts) {
+			urls = append(urls, baseURL+"/resourceInstances/"+normalizeComponentInstanceName(ci.Name)+
+				"/artifacts/"+ci.DeploymentArtifacts[label].ArtifactName)
+		}
+	}
+	if len(urls) == 0 {
+		urls = append(urls, r.ToscaModelURL)
+	}
+	return urls
+}
+
+// distributeComponent activates the distribution of the service i in the
+// environment env: the consumers registered for env are notified of each
+// artifact of the service. Without any consumer registered for env, the
+// distribution gets the sample statuses.
+func distributeComponent(i int, env string, user User) (int, *SdcError) {
+	r := resourceList[i]
+	if r.Archived {
+		return http.StatusBadRequest, archivedComponentError(r)
+	}
+	if r.LifecycleState != "CERTIFIED" ||
+		(r.DistributionStatus != "DISTRIBUTION_APPROVED" && r.DistributionStatus != "DISTRIBUTED") {
+		return http.StatusBadRequest, &SdcError{
+			Message:   "Cannot perform this action",
+			ErrorCode: "SVC3642",
+			Status:    "Bad Action"}
+	}
+	distribution := Distribution{
+		ID:        uuid.NewV4().String(),
+		EnvName:   env,
+		UserID:    user.UserID,
+		Timestamp: time.Now().UnixNano() / 1000000,
+		Statuses:  []DistributionStatus{},
+	}
+	for _, consumer := range distributionConsumers {
+		if consumer.EnvName != env {
+			continue
+		}
+		for _, url := range distributedArtifactURLs(r) {
+			distribution.Statuses = append(distribution.Statuses, DistributionStatus{
+				OmfComponentID: consumer.ConsumerID,
+				Timestamp:      strconv.FormatInt(distribution.Timestamp, 10),
+				URL:            url,
+				Status:         "NOTIFIED",
+				ErrorReason:    "null",
+			})
+		}
+	}
+	if len(distribution.Statuses) == 0 {
+		distribution.Statuses = append(distribution.Statuses, distributionList...)
+	}
+	resourceList[i].Distributions = append(resourceList[i].Distributions, distribution)
+	resourceList[i].DistributionID = distribution.ID
+	resourceList[i].DistributionStatus = "DISTRIBUTED"
+	resourceList[i].DistributerUserID = user.UserID
+	return http.StatusOK, nil
+}
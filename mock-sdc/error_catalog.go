@@ -0,0 +1,73 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrorDefinition describes an error of SDC error configuration
+type ErrorDefinition struct {
+	Code      int    `yaml:"code"`
+	Message   string `yaml:"message"`
+	MessageID string `yaml:"messageId"`
+}
+
+// ErrorConfiguration is SDC error configuration
+type ErrorConfiguration struct {
+	Errors map[string]ErrorDefinition `yaml:"errors"`
+}
+
+var errorCatalog map[string]ErrorDefinition
+
+func loadErrorCatalog() error {
+	data, err := readConfiguration("ERROR_CONFIGURATION_FILE", "error-configuration.yaml")
+	if err != nil {
+		return err
+	}
+	configuration := new(ErrorConfiguration)
+	if err := yaml.Unmarshal(data, configuration); err != nil {
+		return err
+	}
+	errorCatalog = configuration.Errors
+	return nil
+}
+
+// newSdcError builds the error of the catalog with the given name, and
+// returns it with its HTTP status
+func newSdcError(name string, variables ...string) (int, *SdcError) {
+	definition, found := errorCatalog[name]
+	if !found {
+		definition = ErrorDefinition{
+			Code:      http.StatusInternalServerError,
+			Message:   "Error: Internal Server Error. Please try again later.",
+			MessageID: "SVC4001"}
+	}
+	message := definition.Message
+	for i := len(variables); i > 0; i-- {
+		message = strings.ReplaceAll(message, "%"+strconv.Itoa(i), variables[i-1])
+	}
+	return definition.Code, &SdcError{
+		Status:    http.StatusText(definition.Code),
+		ErrorCode: definition.MessageID,
+		Message:   message,
+		Text:      definition.Message,
+		Variables: variables,
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestDigest is the hash of a file given in a SOL004 manifest
+type ManifestDigest struct {
+	Algorithm string
+	Hash      string
+}
+
+// EtsiManifest is the .mf manifest of an ETSI SOL004 package: its metadata,
+// the files it lists with their digests and the non-MANO artifacts by set.
+// Signature is the CMS signature ending the manifest, if any, of the
+// SignedContent before it.
+type EtsiManifest struct {
+	Metadata         map[string]string
+	Sources          []string
+	NonManoArtifacts map[string][]string
+	Digests          map[string]ManifestDigest
+	Signature        []byte
+	SignedContent    []byte
+}
+
+// cmsSignatureHeader starts the CMS signature of a signed manifest
+const cmsSignatureHeader = "-----BEGIN CMS-----"
+
+// PnfSoftwareInformation is the content of a PNF_SW_INFORMATION artifact
+type PnfSoftwareInformation struct {
+	ProductName            string `yaml:"pnf_product_name"`
+	ReleaseVersion         string `yaml:"pnf_release_version"`
+	PnfSoftwareInformation []struct {
+		Version     string `yaml:"pnf_software_version"`
+		Description string `yaml:"description"`
+	} `yaml:"pnf_software_information"`
+}
+
+// pnfManifestMetadata and vnfManifestMetadata are the metadata a SOL004
+// manifest must have, depending on whether it describes a PNF or a VNF
+var pnfManifestMetadata = []string{"pnfd_name", "pnfd_provider", "pnfd_archive_version", "pnfd_release_date_time"}
+var vnfManifestMetadata = []string{"vnf_product_name", "vnf_provider_id", "vnf_package_version", "vnf_release_date_time"}
+
+// nonManoArtifactTypes are the artifact types of the non-MANO artifact sets
+// defined by ONAP
+var nonManoArtifactTypes = map[string]string{
+	"onap_ves_events":         "VES_EVENTS",
+	"onap_pm_dictionary":      "PM_DICTIONARY",
+	"onap_yang_modules":       "YANG_MODULE",
+	"onap_ansible_playbooks":  "ANSIBLE_PLAYBOOK",
+	"onap_pnf_sw_information": "PNF_SW_INFORMATION",
+	"onap_others":             "OTHER",
+}
+
+// etsiManifestName returns the manifest of a SOL004 package, the
+// ETSI-Entry-Manifest of its TOSCA.meta or the .mf file at its root
+func etsiManifestName(files map[string][]byte) string {
+	if meta, found := files["TOSCA-Metadata/TOSCA.meta"]; found {
+		if name := metadataValue(parseToscaMeta(meta), "ETSI-Entry-Manifest", "Entry-Manifest"); name != "" {
+			return name
+		}
+	}
+	for _, name := range sortedKeys(files) {
+		if !strings.Contains(name, "/") && strings.HasSuffix(name, ".mf") {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseEtsiManifest reads the metadata, Source and non_mano_artifact_sets
+// sections of a SOL004 manifest. The Algorithm and Hash following a Source
+// are its digest.
+func parseEtsiManifest(data []byte) (EtsiManifest, error) {
+	manifest := EtsiManifest{
+		Metadata:         map[string]string{},
+		NonManoArtifacts: map[string][]string{},
+		Digests:          map[string]ManifestDigest{},
+	}
+	if i := bytes.Index(data, []byte(cmsSignatureHeader)); i >= 0 {
+		block, _ := pem.Decode(data[i:])
+		if block == nil {
+			return manifest, errors.New("invalid CMS signature")
+		}
+		manifest.Signature = block.Bytes
+		manifest.SignedContent = data[:i]
+		data = data[:i]
+	}
+	section, set, source := "", "", ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			return manifest, errors.New("invalid manifest line: " + line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		indented := line[0] == ' ' || line[0] == '\t'
+		switch {
+		case key == "Algorithm" && source != "":
+			digest := manifest.Digests[source]
+			digest.Algorithm = value
+			manifest.Digests[source] = digest
+		case key == "Hash" && source != "":
+			digest := manifest.Digests[source]
+			digest.Hash = value
+			manifest.Digests[source] = digest
+		case !indented && value == "":
+			section, set, source = key, "", ""
+		case !indented && key == "Source":
+			section, source = "", value
+			manifest.Sources = append(manifest.Sources, value)
+		case !indented:
+			section, source = "", ""
+		case section == "metadata":
+			manifest.Metadata[key] = value
+		case section == "non_mano_artifact_sets" && value == "":
+			set, source = key, ""
+			manifest.NonManoArtifacts[set] = []string{}
+		case section == "non_mano_artifact_sets" && key == "Source" && set != "":
+			source = value
+			manifest.NonManoArtifacts[set] = append(manifest.NonManoArtifacts[set], value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return manifest, errors.New("invalid manifest: " + err.Error())
+	}
+	if len(manifest.Metadata) == 0 {
+		return manifest, errors.New("manifest has no metadata")
+	}
+	return manifest, nil
+}
+
+// manifestResourceType tells whether a manifest describes a PNF or a VNF,
+// checking that it has all the metadata required for it
+func manifestResourceType(manifest EtsiManifest) (string, error) {
+	resourceType, required := "VF", vnfManifestMetadata
+	for key := range manifest.Metadata {
+		if strings.HasPrefix(key, "pnfd_") {
+			resourceType, required = "PNF", pnfManifestMetadata
+		}
+	}
+	for _, key := range required {
+		if manifest.Metadata[key] == "" {
+			return "", errors.New("manifest metadata " + key + " is missing")
+		}
+	}
+	return resourceType, nil
+}
+
+// parsePnfSoftwareVersions returns the software versions of a
+// PNF_SW_INFORMATION artifact
+func parsePnfSoftwareVersions(name string, data []byte) ([]string, error) {
+	information := PnfSoftwareInformation{}
+	if err := yaml.Unmarshal(data, &information); err != nil {
+		return nil, errors.New("invalid PNF software information " + name + ": " + err.Error())
+	}
+	versions := []string{}
+	for _, software := range information.PnfSoftwareInformation {
+		if strings.TrimSpace(software.Version) == "" {
+			return nil, errors.New("PNF software information " + name + " has an entry without pnf_software_version")
+		}
+		versions = append(versions, software.Version)
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("PNF software information " + name + " has no pnf_software_version")
+	}
+	return versions, nil
+}
+
+// parseEtsiPackage reads the manifest of a SOL004 package. Its non-MANO
+// artifacts become the files of the package manifest, and the software
+// versions of a PNF are read from its PNF_SW_INFORMATION artifacts.
+func parseEtsiPackage(p *OnboardedPackage, manifestName string) error {
+	data, found := p.Files[manifestName]
+	if !found {
+		return errors.New("manifest " + manifestName + " not found in the package")
+	}
+	manifest, err := parseEtsiManifest(data)
+	if err != nil {
+		return errors.New("invalid manifest " + manifestName + ": " + err.Error())
+	}
+	if p.ResourceType, err = manifestResourceType(manifest); err != nil {
+		return errors.New("invalid manifest " + manifestName + ": " + err.Error())
+	}
+	p.EtsiManifest = &manifest
+	if p.Security == nil {
+		p.Security = &SecurityValidation{}
+	}
+	p.verifyManifest(manifestName, manifest)
+	p.Manifest = Manifest{
+		Name:    metadataValue(manifest.Metadata, "pnfd_name", "vnf_product_name"),
+		Version: metadataValue(manifest.Metadata, "pnfd_archive_version", "vnf_package_version"),
+		Data:    []ManifestFile{},
+	}
+	for _, source := range manifest.Sources {
+		if _, found := p.Files[source]; !found {
+			return errors.New("file " + source + " of " + manifestName + " not found in the package")
+		}
+	}
+	for _, set := range sortedKeys(manifest.NonManoArtifacts) {
+		artifactType, known := nonManoArtifactTypes[set]
+		if !known {
+			artifactType = "OTHER"
+		}
+		for _, source := range manifest.NonManoArtifacts[set] {
+			data, found := p.Files[source]
+			if !found {
+				return errors.New("non-MANO artifact " + source + " of " + manifestName + " not found in the package")
+			}
+			if artifactType == "PNF_SW_INFORMATION" {
+				if p.ResourceType != "PNF" {
+					return errors.New("PNF software information " + source + " found in a VNF package")
+				}
+				versions, err := parsePnfSoftwareVersions(path.Base(source), data)
+				if err != nil {
+					return err
+				}
+				p.SoftwareVersions = append(p.SoftwareVersions, versions...)
+			}
+			p.Manifest.Data = append(p.Manifest.Data, ManifestFile{File: source, Type: artifactType})
+		}
+	}
+	return nil
+}
+
+// importSoftwareVersions adds the software versions of a PNF package as the
+// software_versions property of the PNF
+func importSoftwareVersions(resource *Resource, p *OnboardedPackage) {
+	if len(p.SoftwareVersions) == 0 {
+		return
+	}
+	value, _ := json.Marshal(p.SoftwareVersions)
+	property := Property{
+		Name:           "software_versions",
+		Value:          string(value),
+		Type:           "list",
+		UniqueID:       resource.UniqueID + ".software_versions",
+		ParentUniqueID: resource.UniqueID,
+		Description:    "Software versions of the PNF",
+		Schema:         &PropertySchema{Property: SchemaProperty{Type: "string"}},
+	}
+	if i := findProperty(resource.Properties, property.Name); i >= 0 {
+		resource.Properties[i] = property
+	} else {
+		resource.Properties = append(resource.Properties, property)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// externalLifecycleActions maps the lifecycle operations of the external
+// API to the actions of the UI one
+var externalLifecycleActions = map[string]string{
+	"checkin":  "checkin",
+	"checkout": "checkout",
+	"certify":  "Certify",
+}
+
+// externalUser checks the headers the external API requires and returns the
+// calling user if it has one of the roles, or writes the error response
+func externalUser(c echo.Context, roles ...string) (User, bool, error) {
+	if c.Request().Header.Get("X-ECOMP-InstanceID") == "" {
+		return User{}, false, c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Error: Missing 'X-ECOMP-InstanceID' HTTP header.",
+			ErrorCode: "POL5001",
+			Status:    "Missing Information"})
+	}
+	if c.Request().Header.Get("USER_ID") == "" {
+		return User{}, false, c.JSON(http.StatusForbidden, SdcError{
+			Message:   "Error: Missing 'USER_ID' HTTP header.",
+			ErrorCode: "SVC4007",
+			Status:    "Missing Information"})
+	}
+	return authorizeUser(c, roles...)
+}
+
+// externalBaseURL is the external API URL of a component
+func externalBaseURL(r Resource) string {
+	return "/sdc/v1/catalog/" + catalogCollection(r) + "/" + r.ID
+}
+
+// externalComponentIndex returns the index of the component of the uuid
+// parameter, if it belongs to the collection of the route, or writes the
+// error response
+func externalComponentIndex(c echo.Context) (int, error) {
+	i := findResourceUUIDIndex(c.Param("uuid"))
+	if i < 0 || !strings.HasPrefix(c.Path(), "/sdc/v1/catalog/"+catalogCollection(resourceList[i])+"/") {
+		return -1, c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	return i, nil
+}
+
+// externalCheckoutIndex returns the index of a component that the caller of
+// the external API may modify, along with the caller, or writes the error
+// response. Handlers set the caller as last updater once their change is made.
+func externalCheckoutIndex(c echo.Context) (int, User, error) {
+	user, ok, err := externalUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return -1, user, err
+	}
+	i, err := externalComponentIndex(c)
+	if i < 0 {
+		return i, user, err
+	}
+	if resourceList[i].LifecycleState != "NOT_CERTIFIED_CHECKOUT" {
+		return -1, user, c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Cannot perform this action",
+			ErrorCode: "SVC3642",
+			Status:    "Bad Action"})
+	}
+	if resourceList[i].Archived {
+		return -1, user, c.JSON(http.StatusBadRequest, archivedComponentError(resourceList[i]))
+	}
+	if sdcError := checkLock(resourceList[i], user); sdcError != nil {
+		return -1, user, c.JSON(http.StatusForbidden, sdcError)
+	}
+	return i, user, nil
+}
+
+// postExternalComponent creates a resource or a service, depending on the
+// collection of the route
+func postExternalComponent(c echo.Context) error {
+	user, ok, err := externalUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return err
+	}
+	resource := new(Resource)
+	if err := c.Bind(resource); err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	if strings.HasPrefix(c.Path(), "/sdc/v1/catalog/services") {
+		resource.ComponentType = "SERVICE"
+	} else if resource.ComponentType == "" || resource.ComponentType == "SERVICE" {
+		resource.ComponentType = "RESOURCE"
+	}
+	if status, sdcError := registerComponent(resource, user); sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	return c.JSON(http.StatusCreated, componentMetadata(*resource))
+}
+
+func postExternalLifecycleState(c echo.Context) error {
+	action, found := externalLifecycleActions[strings.ToLower(c.Param("operation"))]
+	if !found {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid lifecycle operation " + c.Param("operation") + ".",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	user, ok, err := externalUser(c, actionRoles(action)...)
+	if !ok {
+		return err
+	}
+	actionBody := new(ActionBody)
+	if err := c.Bind(actionBody); err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	i, err := externalComponentIndex(c)
+	if i < 0 {
+		return err
+	}
+	j, status, sdcError := changeLifecycleState(i, action, user)
+	if sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	return c.JSON(http.StatusCreated, componentMetadata(resourceList[j]))
+}
+
+func postExternalArtifact(c echo.Context) error {
+	i, user, err := externalCheckoutIndex(c)
+	if i < 0 {
+		return err
+	}
+	body := new(ArtifactAdd)
+	if err := c.Bind(body); err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	artifact, status, sdcError := addArtifact(&resourceList[i], *body)
+	if sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, artifactMetadata(externalBaseURL(resourceList[i]), artifact))
+}
+
+func updateExternalArtifact(c echo.Context) error {
+	i, user, err := externalCheckoutIndex(c)
+	if i < 0 {
+		return err
+	}
+	r := &resourceList[i]
+	artifacts, label := findArtifact(r, c.Param("artifactUUID"))
+	if artifacts == nil {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Artifact " + c.Param("artifactUUID") + " not found.",
+			ErrorCode: "SVC4128",
+			Status:    "Not Found"})
+	}
+	body := new(ArtifactAdd)
+	if err := c.Bind(body); err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	artifact, sdcError := updateArtifact(r, artifacts, label, *body)
+	if sdcError != nil {
+		return c.JSON(http.StatusBadRequest, sdcError)
+	}
+	setLastUpdater(r, user)
+	return c.JSON(http.StatusOK, artifactMetadata(externalBaseURL(*r), artifact))
+}
+
+func deleteExternalArtifact(c echo.Context) error {
+	i, user, err := externalCheckoutIndex(c)
+	if i < 0 {
+		return err
+	}
+	artifacts, label := findArtifact(&resourceList[i], c.Param("artifactUUID"))
+	if artifacts == nil {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Artifact " + c.Param("artifactUUID") + " not found.",
+			ErrorCode: "SVC4128",
+			Status:    "Not Found"})
+	}
+	artifact := (*artifacts)[label]
+	delete(*artifacts, label)
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, artifactMetadata(externalBaseURL(resourceList[i]), artifact))
+}
+
+func postExternalInstanceArtifact(c echo.Context) error {
+	i, user, err := externalCheckoutIndex(c)
+	if i < 0 {
+		return err
+	}
+	r := &resourceList[i]
+	j := -1
+	for k, ci := range r.ComponentInstances {
+		if normalizeComponentInstanceName(ci.Name) == c.Param("instanceName") {
+			j = k
+		}
+	}
+	if j < 0 {
+		return c.JSON(newSdcError("COMPONENT_INSTANCE_NOT_FOUND", c.Param("instanceName")))
+	}
+	body := new(ArtifactAdd)
+	if err := c.Bind(body); err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	artifact, sdcError := addInstanceArtifact(r, j, *body)
+	if sdcError != nil {
+		return c.JSON(http.StatusBadRequest, sdcError)
+	}
+	setLastUpdater(r, user)
+	return c.JSON(http.StatusOK, artifactMetadata(externalBaseURL(*r)+"/resourceInstances/"+c.Param("instanceName"), artifact))
+}
@@ -25,8 +25,18 @@ func index(c echo.Context) error {
 }
 
 func reset(c echo.Context) error {
+	generateInitialUserList()
+	distributionConsumers = nil
 	generateInitialVendorList()
 	generateInitialVspList()
-	generateInitialResourceList()
+	if err := loadModels(); err != nil {
+		return err
+	}
+	if err := generateInitialCategories(); err != nil {
+		return err
+	}
+	if err := generateInitialResourceList(); err != nil {
+		return err
+	}
 	return c.String(http.StatusCreated, "reset done!")
 }
@@ -6,6 +6,7 @@ require (
 	github.com/labstack/echo v3.3.10+incompatible
 	github.com/labstack/gommon v0.4.2
 	github.com/satori/go.uuid v1.2.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
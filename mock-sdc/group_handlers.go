@@ -0,0 +1,298 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Group describes a group of a component, such as a VF module
+type Group struct {
+	UniqueID          string            `json:"uniqueId"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"`
+	Version           string            `json:"version"`
+	GroupUUID         string            `json:"groupUUID"`
+	InvariantUUID     string            `json:"invariantUUID"`
+	CustomizationUUID string            `json:"customizationUUID"`
+	Description       string            `json:"description"`
+	Members           map[string]string `json:"members"`
+	Artifacts         []string          `json:"artifacts"`
+	ArtifactsUUID     []string          `json:"artifactsUuid"`
+	Properties        []Property        `json:"properties"`
+}
+
+// GroupInstance is a group of the origin of a component instance, such as
+// a VF module of a VF used in a service
+type GroupInstance struct {
+	UniqueID          string     `json:"uniqueId"`
+	Name              string     `json:"name"`
+	NormalizedName    string     `json:"normalizedName"`
+	GroupName         string     `json:"groupName"`
+	GroupUID          string     `json:"groupUid"`
+	GroupUUID         string     `json:"groupUUID"`
+	InvariantUUID     string     `json:"invariantUUID"`
+	CustomizationUUID string     `json:"customizationUUID"`
+	Version           string     `json:"version"`
+	Type              string     `json:"type"`
+	Artifacts         []string   `json:"artifacts"`
+	ArtifactsUUID     []string   `json:"groupInstanceArtifactsUuid"`
+	Properties        []Property `json:"properties"`
+}
+
+// newGroupInstance instantiates a group of the origin of a component instance
+func newGroupInstance(ci ComponentInstance, g Group) GroupInstance {
+	name := ci.NormalizedName + ".." + g.Name
+	properties := []Property{}
+	for _, p := range g.Properties {
+		p.UniqueID = ci.UniqueID + "." + name + "." + p.Name
+		p.ParentUniqueID = ci.UniqueID + "." + name
+		properties = append(properties, p)
+	}
+	return GroupInstance{
+		UniqueID:          ci.UniqueID + "." + name,
+		Name:              name,
+		NormalizedName:    normalizeComponentInstanceName(name),
+		GroupName:         g.Name,
+		GroupUID:          g.UniqueID,
+		GroupUUID:         g.GroupUUID,
+		InvariantUUID:     g.InvariantUUID,
+		CustomizationUUID: uuid.NewV4().String(),
+		Version:           g.Version,
+		Type:              g.Type,
+		Artifacts:         append([]string{}, g.Artifacts...),
+		ArtifactsUUID:     append([]string{}, g.ArtifactsUUID...),
+		Properties:        properties,
+	}
+}
+
+// vfModuleInstances instantiates the VF modules of the origin of a
+// component instance
+func vfModuleInstances(ci ComponentInstance, origin Resource) []GroupInstance {
+	instances := []GroupInstance{}
+	for _, g := range origin.Groups {
+		if g.Type == "org.openecomp.groups.VfModule" {
+			instances = append(instances, newGroupInstance(ci, g))
+		}
+	}
+	return instances
+}
+
+// vfModuleProperty builds a property of a VF module group
+func vfModuleProperty(group Group, name string, typeName string, value string) Property {
+	return Property{
+		Name:           name,
+		Value:          value,
+		Type:           typeName,
+		UniqueID:       group.UniqueID + "." + name,
+		ParentUniqueID: group.UniqueID,
+	}
+}
+
+// importPackageModules adds the files of an onboarded package as deployment
+// artifacts of a VF, and one org.openecomp.groups.VfModule group per Heat
+// module or Helm chart
+func importPackageModules(resource *Resource, p *OnboardedPackage) {
+	initResourceCollections(resource)
+	artifacts := map[string]Artifact{}
+	for _, f := range p.manifestFiles() {
+		body := ArtifactAdd{
+			ArtifactName:      path.Base(f.File),
+			ArtifactLabel:     f.File,
+			ArtifactType:      f.Type,
+			ArtifactGroupType: "DEPLOYMENT",
+			Description:       "created from csar",
+		}
+		if chart, found := p.Charts[f.File]; found {
+			body.Description = "Helm chart " + chart.Name + " version " + chart.Version
+		}
+		if a, found := findArtifactType(f.Type); !found || !containsString(a.Categories, "DEPLOYMENT") {
+			body.ArtifactGroupType = "INFORMATIONAL"
+			if !found {
+				body.ArtifactType = "OTHER"
+			}
+		}
+		artifact, sdcError := newArtifactFromPayload(body, p.Files[f.File], resource.UniqueID)
+		if sdcError != nil {
+			continue
+		}
+		(*artifactGroup(resource, body.ArtifactGroupType))[artifact.ArtifactLabel] = artifact
+		artifacts[f.File] = artifact
+	}
+	for n, module := range p.vfModules() {
+		name := path.Base(module.File)
+		name = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, ".yaml"), ".yml"), ".tgz")
+		group := Group{
+			UniqueID:          resource.UniqueID + "." + toscaName(resource.Name) + ".." + name + "..module-" + strconv.Itoa(n),
+			Name:              toscaName(resource.Name) + ".." + name + "..module-" + strconv.Itoa(n),
+			Type:              "org.openecomp.groups.VfModule",
+			Version:           "1",
+			GroupUUID:         uuid.NewV4().String(),
+			InvariantUUID:     uuid.NewV4().String(),
+			CustomizationUUID: uuid.NewV4().String(),
+			Description:       module.File,
+			Members:           map[string]string{},
+			Artifacts:         []string{},
+			ArtifactsUUID:     []string{},
+		}
+		volumeGroup := false
+		for _, f := range flattenManifestFiles([]ManifestFile{module}) {
+			if artifact, found := artifacts[f.File]; found {
+				group.Artifacts = append(group.Artifacts, artifact.UniqueID)
+				group.ArtifactsUUID = append(group.ArtifactsUUID, artifact.ArtifactUUID)
+			}
+			if f.Type == "HEAT_VOL" {
+				volumeGroup = true
+			}
+		}
+		moduleType, minCount, maxCount, initialCount := "Expansion", "0", "", "0"
+		if module.IsBase {
+			moduleType, minCount, maxCount, initialCount = "Base", "1", "1", "1"
+		}
+		group.Properties = []Property{
+			vfModuleProperty(group, "availability_zone_count", "integer", ""),
+			vfModuleProperty(group, "initial_count", "integer", initialCount),
+			vfModuleProperty(group, "isBase", "boolean", strconv.FormatBool(module.IsBase)),
+			vfModuleProperty(group, "max_vf_module_instances", "integer", maxCount),
+			vfModuleProperty(group, "min_vf_module_instances", "integer", minCount),
+			vfModuleProperty(group, "vf_module_description", "string", ""),
+			vfModuleProperty(group, "vf_module_label", "string", name),
+			vfModuleProperty(group, "vf_module_type", "string", moduleType),
+			vfModuleProperty(group, "volume_group", "boolean", strconv.FormatBool(volumeGroup)),
+		}
+		resource.Groups = append(resource.Groups, group)
+	}
+}
+
+// readOnlyGroupProperties are the VF module properties derived from the Heat
+// package, which cannot be changed
+var readOnlyGroupProperties = []string{"isBase", "vf_module_type", "volume_group"}
+
+func findGroup(groups []Group, groupID string) int {
+	for i, g := range groups {
+		if g.UniqueID == groupID || g.Name == groupID {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateVfModuleCounts checks min_vf_module_instances <= initial_count <=
+// max_vf_module_instances, ignoring the unset ones
+func validateVfModuleCounts(group Group) *SdcError {
+	count := func(name string) (int, bool) {
+		j := findProperty(group.Properties, name)
+		if j < 0 || group.Properties[j].Value == "" {
+			return 0, false
+		}
+		value, err := strconv.Atoi(group.Properties[j].Value)
+		return value, err == nil
+	}
+	minCount, hasMin := count("min_vf_module_instances")
+	maxCount, hasMax := count("max_vf_module_instances")
+	initialCount, hasInitial := count("initial_count")
+	if hasMin && hasMax && minCount > maxCount {
+		_, sdcError := newSdcError("INVALID_PROPERTY_VALUE", "max_vf_module_instances", "integer",
+			"value must not be lower than min_vf_module_instances")
+		return sdcError
+	}
+	if (hasMin && hasInitial && initialCount < minCount) || (hasMax && hasInitial && initialCount > maxCount) {
+		_, sdcError := newSdcError("INVALID_PROPERTY_VALUE", "initial_count", "integer",
+			"value must be between min_vf_module_instances and max_vf_module_instances")
+		return sdcError
+	}
+	return nil
+}
+
+func getResourceGroup(c echo.Context) error {
+	i := findResourceIndex(c.Param("resourceID"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	j := findGroup(resourceList[i].Groups, c.Param("groupID"))
+	if j < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Group " + c.Param("groupID") + " not found.",
+			ErrorCode: "SVC4142",
+			Status:    "Not Found"})
+	}
+	return c.JSON(http.StatusOK, resourceList[i].Groups[j])
+}
+
+// putGroupProperties updates the values of properties of a group
+func putGroupProperties(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	j := findGroup(resourceList[i].Groups, c.Param("groupID"))
+	if j < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Group " + c.Param("groupID") + " not found.",
+			ErrorCode: "SVC4142",
+			Status:    "Not Found"})
+	}
+	updates, err := parsePropertiesBody(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	group := resourceList[i].Groups[j]
+	group.Properties = append([]Property{}, group.Properties...)
+	updated := []Property{}
+	for _, u := range updates {
+		k := findProperty(group.Properties, u.Name)
+		if k < 0 {
+			return c.JSON(http.StatusNotFound, SdcError{
+				Message:   "Property " + u.Name + " not found.",
+				ErrorCode: "SVC4315",
+				Status:    "Not Found"})
+		}
+		p := group.Properties[k]
+		if group.Type == "org.openecomp.groups.VfModule" &&
+			containsString(readOnlyGroupProperties, p.Name) && u.Value != p.Value {
+			return c.JSON(http.StatusBadRequest, SdcError{
+				Message:   "Property " + p.Name + " of group " + group.Name + " cannot be updated.",
+				ErrorCode: "SVC4000",
+				Status:    "Invalid Content"})
+		}
+		p.Value = u.Value
+		if sdcError := validateProperty(p, modelTypes(resourceList[i].Model)); sdcError != nil {
+			return c.JSON(http.StatusBadRequest, sdcError)
+		}
+		group.Properties[k] = p
+		updated = append(updated, p)
+	}
+	if group.Type == "org.openecomp.groups.VfModule" {
+		if sdcError := validateVfModuleCounts(group); sdcError != nil {
+			return c.JSON(http.StatusBadRequest, sdcError)
+		}
+	}
+	group.CustomizationUUID = uuid.NewV4().String()
+	resourceList[i].Groups[j] = group
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ManifestFile is an entry of the MANIFEST.json of an onboarded package.
+// Data holds the files attached to it, such as the env of a Heat template.
+type ManifestFile struct {
+	File   string         `json:"file"`
+	Type   string         `json:"type"`
+	IsBase bool           `json:"isBase,omitempty"`
+	Data   []ManifestFile `json:"data,omitempty"`
+}
+
+// UnmarshalJSON reads a manifest entry whose isBase may also be given as a
+// "true" or "false" string, as in the CNF packages of ONAP
+func (f *ManifestFile) UnmarshalJSON(data []byte) error {
+	type manifestFile ManifestFile
+	entry := struct {
+		manifestFile
+		IsBase interface{} `json:"isBase"`
+	}{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+	*f = ManifestFile(entry.manifestFile)
+	switch isBase := entry.IsBase.(type) {
+	case bool:
+		f.IsBase = isBase
+	case string:
+		f.IsBase = strings.EqualFold(isBase, "true")
+	case nil:
+	default:
+		return errors.New("invalid isBase of " + f.File)
+	}
+	return nil
+}
+
+// Manifest is the MANIFEST.json describing an onboarded package
+type Manifest struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Version     string         `json:"version"`
+	Data        []ManifestFile `json:"data"`
+}
+
+// OnboardedPackage is the network package uploaded to a VSP. ResourceType
+// is the type of the resources created from it, PNF or VF. EtsiManifest,
+// SoftwareVersions and Security are only set for ETSI SOL004 packages, the
+// failed checks of their hashes and signatures being in SecurityErrors by
+// file. Charts holds the Helm charts of the HELM files by path.
+type OnboardedPackage struct {
+	Files            map[string][]byte
+	Manifest         Manifest
+	ResourceType     string
+	EtsiManifest     *EtsiManifest
+	SoftwareVersions []string
+	Security         *SecurityValidation
+	SecurityErrors   map[string][]ErrorMessage
+	Charts           map[string]HelmChart
+}
+
+// parseOnboardedPackage reads a zip network package, either an ETSI SOL004
+// CSAR, signed as a whole or not, or a Heat or Helm package. The
+// MANIFEST.json of a Heat package is generated from the file names when the
+// package has none.
+func parseOnboardedPackage(payload []byte) (*OnboardedPackage, error) {
+	files, err := readZip(payload)
+	if err != nil {
+		return nil, err
+	}
+	p := &OnboardedPackage{Files: files, ResourceType: "VF"}
+	if csarName, signature, certificate, found := signedCsar(files); found {
+		if p.Files, err = readZip(files[csarName]); err != nil {
+			return nil, errors.New("invalid CSAR " + csarName + ": " + err.Error())
+		}
+		p.Security = &SecurityValidation{VerifiedHashes: []string{}}
+		p.verifySignature(csarName, signature, files[csarName], certificate)
+		files = p.Files
+	}
+	if manifestName := etsiManifestName(files); manifestName != "" {
+		if err := parseEtsiPackage(p, manifestName); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	if manifest, found := files["MANIFEST.json"]; found {
+		if err := json.Unmarshal(manifest, &p.Manifest); err != nil {
+			return nil, errors.New("invalid MANIFEST.json: " + err.Error())
+		}
+		for _, f := range p.manifestFiles() {
+			if _, found := files[f.File]; !found {
+				return nil, errors.New("file " + f.File + " of MANIFEST.json not found in the package")
+			}
+		}
+		if err := p.parseHelmCharts(); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	p.Manifest = generateManifest(files)
+	return p, nil
+}
+
+// generateManifest describes the files of a Heat package without manifest:
+// each template is a module, base if its name says so, with the env file of
+// the same name attached
+func generateManifest(files map[string][]byte) Manifest {
+	manifest := Manifest{Data: []ManifestFile{}}
+	for _, name := range sortedKeys(files) {
+		base := strings.TrimSuffix(strings.TrimSuffix(name, ".yaml"), ".yml")
+		switch {
+		case base != name && strings.Contains(string(files[name]), "heat_template_version"):
+			module := ManifestFile{File: name, Type: "HEAT", IsBase: strings.Contains(strings.ToLower(base), "base")}
+			if _, found := files[base+".env"]; found {
+				module.Data = []ManifestFile{{File: base + ".env", Type: "HEAT_ENV"}}
+			}
+			manifest.Data = append(manifest.Data, module)
+		case strings.HasSuffix(name, ".env"):
+			if _, found := files[strings.TrimSuffix(name, ".env")+".yaml"]; found {
+				continue
+			}
+			if _, found := files[strings.TrimSuffix(name, ".env")+".yml"]; found {
+				continue
+			}
+			manifest.Data = append(manifest.Data, ManifestFile{File: name, Type: "HEAT_ENV"})
+		default:
+			manifest.Data = append(manifest.Data, ManifestFile{File: name, Type: "OTHER"})
+		}
+	}
+	return manifest
+}
+
+func flattenManifestFiles(files []ManifestFile) []ManifestFile {
+	flat := []ManifestFile{}
+	for _, f := range files {
+		flat = append(flat, f)
+		flat = append(flat, flattenManifestFiles(f.Data)...)
+	}
+	return flat
+}
+
+// manifestFiles lists all the files described by the manifest
+func (p *OnboardedPackage) manifestFiles() []ManifestFile {
+	return flattenManifestFiles(p.Manifest.Data)
+}
+
+// fileNames lists the files of the package
+func (p *OnboardedPackage) fileNames() []string {
+	names := []string{}
+	for _, name := range sortedKeys(p.Files) {
+		if name != "MANIFEST.json" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isModule tells whether a manifest entry becomes a VF module: Heat
+// templates and Helm charts do
+func isModule(f ManifestFile) bool {
+	return f.Type == "HEAT" || f.Type == "HELM"
+}
+
+// vfModules lists the Heat templates and Helm charts that become VF
+// modules, base first
+func (p *OnboardedPackage) vfModules() []ManifestFile {
+	modules := []ManifestFile{}
+	for _, f := range p.Manifest.Data {
+		if isModule(f) && f.IsBase {
+			modules = append(modules, f)
+		}
+	}
+	for _, f := range p.Manifest.Data {
+		if isModule(f) && !f.IsBase {
+			modules = append(modules, f)
+		}
+	}
+	return modules
+}
+
+// hasType tells whether the manifest has a file of the given type
+func (p *OnboardedPackage) hasType(fileType string) bool {
+	for _, f := range p.manifestFiles() {
+		if f.Type == fileType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HelmChart is the Chart.yaml of a Helm chart of an onboarded package
+type HelmChart struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	AppVersion string `yaml:"appVersion"`
+}
+
+// parseHelmChart reads the Chart.yaml at the root of the chart of a Helm
+// tarball
+func parseHelmChart(name string, data []byte) (HelmChart, error) {
+	chart := HelmChart{}
+	if !strings.HasSuffix(name, ".tgz") {
+		return chart, errors.New("Helm chart " + name + " is not a .tgz archive")
+	}
+	archive, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return chart, errors.New("Helm chart " + name + " is not a gzip archive")
+	}
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return chart, errors.New("Helm chart " + name + " is not a tar archive")
+		}
+		dir, file := path.Split(strings.TrimPrefix(header.Name, "./"))
+		if file != "Chart.yaml" || strings.Count(dir, "/") != 1 {
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return chart, err
+		}
+		if err := yaml.Unmarshal(content, &chart); err != nil {
+			return chart, errors.New("invalid Chart.yaml in " + name + ": " + err.Error())
+		}
+		if chart.Name == "" || chart.Version == "" {
+			return chart, errors.New("Chart.yaml of " + name + " has no name or version")
+		}
+		return chart, nil
+	}
+	return chart, errors.New("no Chart.yaml found in Helm chart " + name)
+}
+
+// parseHelmCharts reads the charts of the HELM files of a package manifest
+func (p *OnboardedPackage) parseHelmCharts() error {
+	for _, f := range p.manifestFiles() {
+		if f.Type != "HELM" {
+			continue
+		}
+		chart, err := parseHelmChart(f.File, p.Files[f.File])
+		if err != nil {
+			return err
+		}
+		if p.Charts == nil {
+			p.Charts = map[string]HelmChart{}
+		}
+		p.Charts[f.File] = chart
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// CatalogComponent is the light description of a component shown in the
+// catalog screen
+type CatalogComponent struct {
+	UniqueID           string   `json:"uniqueId"`
+	ID                 string   `json:"uuid"`
+	InvariantID        string   `json:"invariantUUID"`
+	Name               string   `json:"name"`
+	Version            string   `json:"version"`
+	ComponentType      string   `json:"componentType"`
+	ResourceType       string   `json:"resourceType,omitempty"`
+	Icon               string   `json:"icon"`
+	Category           string   `json:"category"`
+	SubCategory        string   `json:"subCategory,omitempty"`
+	LifecycleState     string   `json:"lifecycleState"`
+	DistributionStatus string   `json:"distributionStatus,omitempty"`
+	LastUpdaterUserID  string   `json:"lastUpdaterUserId"`
+	Tags               []string `json:"tags"`
+	Model              string   `json:"model,omitempty"`
+}
+
+// CatalogScreen is a page of the catalog screen
+type CatalogScreen struct {
+	Total     int                `json:"total"`
+	Resources []CatalogComponent `json:"resources"`
+	Services  []CatalogComponent `json:"services"`
+}
+
+// FollowedList is a page of the components a user works on, grouped by
+// lifecycle state
+type FollowedList struct {
+	Total           int                      `json:"total"`
+	LifecycleStates map[string]*ResourceList `json:"lifecycleStates"`
+}
+
+// lifecycleStates is the order of the lifecycle states in the home screen
+var lifecycleStates = []string{"NOT_CERTIFIED_CHECKOUT", "NOT_CERTIFIED_CHECKIN", "CERTIFIED"}
+
+func lifecycleStateRank(state string) int {
+	for i, s := range lifecycleStates {
+		if s == state {
+			return i
+		}
+	}
+	return len(lifecycleStates)
+}
+
+// pageBounds returns the part of a list of total elements selected by the
+// start and limit parameters, or writes the error response
+func pageBounds(c echo.Context, total int) (int, int, bool, error) {
+	start, limit := 0, total
+	var err error
+	if c.QueryParam("start") != "" {
+		if start, err = strconv.Atoi(c.QueryParam("start")); err != nil || start < 0 {
+			return 0, 0, false, c.JSON(http.StatusBadRequest, SdcError{
+				Message:   "Invalid start parameter " + c.QueryParam("start") + ".",
+				ErrorCode: "SVC4000",
+				Status:    "Invalid Content"})
+		}
+	}
+	if c.QueryParam("limit") != "" {
+		if limit, err = strconv.Atoi(c.QueryParam("limit")); err != nil || limit < 1 {
+			return 0, 0, false, c.JSON(http.StatusBadRequest, SdcError{
+				Message:   "Invalid limit parameter " + c.QueryParam("limit") + ".",
+				ErrorCode: "SVC4000",
+				Status:    "Invalid Content"})
+		}
+	}
+	if start > total {
+		start = total
+	}
+	end := total
+	if limit < total-start {
+		end = start + limit
+	}
+	return start, end, true, nil
+}
+
+// isFollowed tells whether the user works on the component r: designers on
+// the ones they changed last, testers on the ones to certify, governors and
+// operators on the services to approve or to distribute. Anonymous callers
+// follow the whole catalog.
+func isFollowed(r Resource, user User) bool {
+	switch user.Role {
+	case "":
+		return true
+	case "TESTER":
+		return r.LifecycleState == "NOT_CERTIFIED_CHECKIN" ||
+			(r.LifecycleState == "CERTIFIED" && r.LastUpdaterUserID == user.UserID)
+	case "GOVERNOR":
+		return r.ComponentType == "SERVICE" && r.LifecycleState == "CERTIFIED"
+	case "OPS":
+		return r.ComponentType == "SERVICE" && r.LifecycleState == "CERTIFIED" &&
+			(r.DistributionStatus == "DISTRIBUTION_APPROVED" || r.DistributionStatus == "DISTRIBUTED")
+	}
+	return r.LastUpdaterUserID == user.UserID
+}
+
+// getFollowed returns the last versions of the components the caller works
+// on
+func getFollowed(c echo.Context) error {
+	user, ok, err := authorizeUser(c, userRoles...)
+	if !ok {
+		return err
+	}
+	followed := []Resource{}
+	for _, r := range resourceList {
+		if !r.Archived && isHighestVersion(r) && isFollowed(r, user) {
+			followed = append(followed, r)
+		}
+	}
+	sort.SliceStable(followed, func(i, j int) bool {
+		if rank := lifecycleStateRank(followed[i].LifecycleState) - lifecycleStateRank(followed[j].LifecycleState); rank != 0 {
+			return rank < 0
+		}
+		return strings.ToLower(followed[i].Name) < strings.ToLower(followed[j].Name)
+	})
+	start, end, ok, err := pageBounds(c, len(followed))
+	if !ok {
+		return err
+	}
+	list := FollowedList{Total: len(followed), LifecycleStates: map[string]*ResourceList{}}
+	for _, r := range followed[start:end] {
+		group, found := list.LifecycleStates[r.LifecycleState]
+		if !found {
+			group = &ResourceList{Resources: []Resource{}, Services: []Resource{}}
+			list.LifecycleStates[r.LifecycleState] = group
+		}
+		if r.ComponentType == "SERVICE" {
+			group.Services = append(group.Services, r)
+		} else {
+			group.Resources = append(group.Resources, r)
+		}
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// isExcludedType tells whether the component r has one of the types of the
+// excludeTypes parameters, which may be repeated or comma separated
+func isExcludedType(r Resource, excludeTypes []string) bool {
+	for _, param := range excludeTypes {
+		for _, excluded := range strings.Split(param, ",") {
+			excluded = strings.TrimSpace(excluded)
+			if strings.EqualFold(excluded, r.ComponentType) ||
+				(r.ComponentType != "SERVICE" && strings.EqualFold(excluded, r.ResourceType)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func catalogComponent(r Resource) CatalogComponent {
+	return CatalogComponent{
+		UniqueID:           r.UniqueID,
+		ID:                 r.ID,
+		InvariantID:        r.InvariantID,
+		Name:               r.Name,
+		Version:            r.Version,
+		ComponentType:      r.ComponentType,
+		ResourceType:       r.ResourceType,
+		Icon:               r.Icon,
+		Category:           r.Category,
+		SubCategory:        r.SubCategory,
+		LifecycleState:     r.LifecycleState,
+		DistributionStatus: r.DistributionStatus,
+		LastUpdaterUserID:  r.LastUpdaterUserID,
+		Tags:               nonNil(r.Tags, []string{}),
+		Model:              r.Model,
+	}
+}
+
+// getScreen returns the last versions of the components of the catalog,
+// resources first, without the excluded types and in the requested model
+func getScreen(c echo.Context) error {
+	if _, ok, err := authorizeUser(c, userRoles...); !ok {
+		return err
+	}
+	var resources, services []Resource
+	excludeTypes := c.QueryParams()["excludeTypes"]
+	for _, r := range resourceList {
+		if r.Archived || !isHighestVersion(r) || !inModel(c, r) || isExcludedType(r, excludeTypes) {
+			continue
+		}
+		if r.ComponentType == "SERVICE" {
+			services = append(services, r)
+		} else {
+			resources = append(resources, r)
+		}
+	}
+	components := append(resources, services...)
+	start, end, ok, err := pageBounds(c, len(components))
+	if !ok {
+		return err
+	}
+	screen := CatalogScreen{Total: len(components), Resources: []CatalogComponent{}, Services: []CatalogComponent{}}
+	for _, r := range components[start:end] {
+		if r.ComponentType == "SERVICE" {
+			screen.Services = append(screen.Services, catalogComponent(r))
+		} else {
+			screen.Resources = append(screen.Resources, catalogComponent(r))
+		}
+	}
+	return c.JSON(http.StatusOK, screen)
+}
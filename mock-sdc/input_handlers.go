@@ -0,0 +1,221 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// Input format
+type Input struct {
+	Name             string          `json:"name"`
+	Value            string          `json:"value"`
+	Type             string          `json:"type"`
+	UniqueID         string          `json:"uniqueId"`
+	Description      string          `json:"description"`
+	Schema           *PropertySchema `json:"schema,omitempty"`
+	InstanceUniqueID string          `json:"instanceUniqueId,omitempty"`
+	PropertyID       string          `json:"propertyId,omitempty"`
+}
+
+// ComponentInstInputsMap is the body used to declare inputs in SDC, keyed by
+// component instance unique id, or by the component unique id for the
+// properties of the component itself
+type ComponentInstInputsMap struct {
+	ComponentInstanceInputsMap  map[string][]PropertyBody `json:"componentInstanceInputsMap"`
+	ComponentInstanceProperties map[string][]PropertyBody `json:"componentInstanceProperties"`
+	ServiceProperties           map[string][]PropertyBody `json:"serviceProperties"`
+}
+
+// inputsDeclaration is the body of a declaration of inputs: the maps of SDC,
+// or a single input added as is
+type inputsDeclaration struct {
+	ComponentInstInputsMap
+	Input
+}
+
+// getInputValue is the value of a property or an input declared as input
+func getInputValue(inputName string) string {
+	value, _ := json.Marshal(map[string]string{"get_input": inputName})
+	return string(value)
+}
+
+func findInput(inputs []Input, inputID string) int {
+	for i, input := range inputs {
+		if input.UniqueID == inputID || input.Name == inputID {
+			return i
+		}
+	}
+	return -1
+}
+
+// postResourceInputs declares component inputs from the properties and the
+// inputs of its component instances. Each declared input is named
+// <instance>_<property> and the instance value becomes a get_input on it.
+// The properties of the component itself are declared as inputs of the same
+// name, and a body with a single input adds it as is.
+func postResourceInputs(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	declared := new(inputsDeclaration)
+	if err := c.Bind(declared); err != nil {
+		return c.JSON(newSdcError("INVALID_CONTENT"))
+	}
+	r := &resourceList[i]
+	// the whole request is checked before the resource is changed: each
+	// declared input is kept with the value to turn into a get_input on it
+	type declaration struct {
+		input Input
+		value *string
+	}
+	declarations := []declaration{}
+	declare := func(input Input, value *string) *SdcError {
+		exists := findInput(r.Inputs, input.Name) >= 0
+		for _, d := range declarations {
+			exists = exists || d.input.Name == input.Name
+		}
+		if exists {
+			return &SdcError{
+				Message:   "Input with " + input.Name + " name already exists.",
+				ErrorCode: "SVC4101",
+				Status:    "Exists"}
+		}
+		input.UniqueID = r.UniqueID + "." + input.Name
+		declarations = append(declarations, declaration{input, value})
+		return nil
+	}
+	// a declared property keeps its value unless it is itself a get function
+	propertyValue := func(value string) string {
+		if isGetFunction(value) {
+			return ""
+		}
+		return value
+	}
+	instanceInput := func(instanceID string, name string, p Property) Input {
+		j := findComponentInstance(r.ComponentInstances, instanceID)
+		return Input{
+			Name:             normalizeComponentInstanceName(r.ComponentInstances[j].Name) + "_" + name,
+			Type:             p.Type,
+			Value:            propertyValue(p.Value),
+			Description:      p.Description,
+			Schema:           p.Schema,
+			InstanceUniqueID: r.ComponentInstances[j].UniqueID,
+			PropertyID:       p.UniqueID,
+		}
+	}
+	for _, instanceID := range sortedKeys(declared.ComponentInstanceProperties) {
+		if findComponentInstance(r.ComponentInstances, instanceID) < 0 {
+			return c.JSON(newSdcError("COMPONENT_INSTANCE_NOT_FOUND", instanceID))
+		}
+		properties := r.ComponentInstancesProperties[instanceID]
+		for _, body := range declared.ComponentInstanceProperties[instanceID] {
+			k := findProperty(properties, body.Name)
+			if k < 0 {
+				return c.JSON(http.StatusNotFound, SdcError{
+					Message:   "Property " + body.Name + " not found.",
+					ErrorCode: "SVC4315",
+					Status:    "Not Found"})
+			}
+			input := instanceInput(instanceID, properties[k].Name, properties[k])
+			if sdcError := declare(input, &properties[k].Value); sdcError != nil {
+				return c.JSON(http.StatusBadRequest, sdcError)
+			}
+		}
+	}
+	for _, instanceID := range sortedKeys(declared.ComponentInstanceInputsMap) {
+		if findComponentInstance(r.ComponentInstances, instanceID) < 0 {
+			return c.JSON(newSdcError("COMPONENT_INSTANCE_NOT_FOUND", instanceID))
+		}
+		inputs := r.ComponentInstancesInputs[instanceID]
+		for _, body := range declared.ComponentInstanceInputsMap[instanceID] {
+			k := findInput(inputs, body.Name)
+			if k < 0 {
+				return c.JSON(http.StatusNotFound, SdcError{
+					Message:   "Input " + body.Name + " not found.",
+					ErrorCode: "SVC4315",
+					Status:    "Not Found"})
+			}
+			p := Property{
+				Name:        inputs[k].Name,
+				Value:       inputs[k].Value,
+				Type:        inputs[k].Type,
+				UniqueID:    inputs[k].UniqueID,
+				Description: inputs[k].Description,
+				Schema:      inputs[k].Schema,
+			}
+			if sdcError := declare(instanceInput(instanceID, inputs[k].Name, p), &inputs[k].Value); sdcError != nil {
+				return c.JSON(http.StatusBadRequest, sdcError)
+			}
+		}
+	}
+	for _, componentID := range sortedKeys(declared.ServiceProperties) {
+		if componentID != r.UniqueID {
+			return c.JSON(http.StatusNotFound, SdcError{
+				Message:   "Component " + componentID + " not found.",
+				ErrorCode: "SVC4642",
+				Status:    "Not Found"})
+		}
+		for _, body := range declared.ServiceProperties[componentID] {
+			k := findProperty(r.Properties, body.Name)
+			if k < 0 {
+				return c.JSON(http.StatusNotFound, SdcError{
+					Message:   "Property " + body.Name + " not found.",
+					ErrorCode: "SVC4315",
+					Status:    "Not Found"})
+			}
+			p := r.Properties[k]
+			input := Input{
+				Name:        p.Name,
+				Type:        p.Type,
+				Value:       propertyValue(p.Value),
+				Description: p.Description,
+				Schema:      p.Schema,
+				PropertyID:  p.UniqueID,
+			}
+			if sdcError := declare(input, &r.Properties[k].Value); sdcError != nil {
+				return c.JSON(http.StatusBadRequest, sdcError)
+			}
+		}
+	}
+	if len(declarations) == 0 && declared.Input.Name != "" {
+		input := declared.Input
+		input.InstanceUniqueID = ""
+		input.PropertyID = ""
+		if sdcError := declare(input, nil); sdcError != nil {
+			return c.JSON(http.StatusBadRequest, sdcError)
+		}
+	}
+	if len(declarations) == 0 {
+		return c.JSON(newSdcError("INVALID_CONTENT"))
+	}
+	created := []Input{}
+	for _, d := range declarations {
+		r.Inputs = append(r.Inputs, d.input)
+		if d.value != nil {
+			*d.value = getInputValue(d.input.Name)
+		}
+		created = append(created, d.input)
+	}
+	for _, input := range created {
+		refreshComponentInstance(r, input.InstanceUniqueID)
+	}
+	setLastUpdater(r, user)
+	return c.JSON(http.StatusOK, created)
+}
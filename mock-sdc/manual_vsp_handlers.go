@@ -0,0 +1,835 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Nic is a network interface of a component of a manual VSP. NetworkType
+// is External or Internal.
+type Nic struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Description        string `json:"description"`
+	NetworkID          string `json:"networkId"`
+	NetworkName        string `json:"networkName"`
+	NetworkType        string `json:"networkType"`
+	NetworkDescription string `json:"networkDescription"`
+}
+
+// ComputeFlavor is a flavor the VMs of a component of a manual VSP may use
+type ComputeFlavor struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Image is an image the VMs of a component of a manual VSP may boot from
+type Image struct {
+	ID          string `json:"id"`
+	FileName    string `json:"fileName"`
+	Description string `json:"description"`
+}
+
+// VspComponent is a VFC of a manual VSP, with its NICs, compute flavors and
+// images
+type VspComponent struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	DisplayName    string          `json:"displayName"`
+	Description    string          `json:"description"`
+	Nics           []Nic           `json:"-"`
+	ComputeFlavors []ComputeFlavor `json:"-"`
+	Images         []Image         `json:"-"`
+}
+
+// ComponentComputeAssociation tells which compute flavor a component uses
+// in a deployment flavor
+type ComponentComputeAssociation struct {
+	ComponentID     string `json:"componentId"`
+	ComputeFlavorID string `json:"computeFlavorId"`
+}
+
+// DeploymentFlavor is a deployment of a manual VSP
+type DeploymentFlavor struct {
+	ID                           string                        `json:"id"`
+	Model                        string                        `json:"model"`
+	Description                  string                        `json:"description"`
+	FeatureGroupID               string                        `json:"featureGroupId"`
+	ComponentComputeAssociations []ComponentComputeAssociation `json:"componentComputeAssociations"`
+}
+
+// ModelList is the way to return the entities of the model of a manual VSP
+type ModelList[T any] struct {
+	ListCount int `json:"listCount"`
+	Results   []T `json:"results"`
+}
+
+// CreatedModelItem is the answer to the creation of an entity of the model
+// of a manual VSP
+type CreatedModelItem struct {
+	ID string `json:"id"`
+}
+
+// manualVspVersion returns the indexes of the manual VSP of the route and of
+// its version, or the error to return
+func manualVspVersion(c echo.Context) (int, int, error) {
+	vspID := c.Param("vspID")
+	versionID := c.Param("versionID")
+	for i, v := range vspList {
+		if v.ID == vspID {
+			for j, version := range v.Versions {
+				if version.ID == versionID {
+					if v.OnboardingMethod != "Manual" {
+						return -1, -1, echo.NewHTTPError(http.StatusBadRequest, "VSP "+v.Name+" is onboarded from a network package")
+					}
+					if version.RealStatus == "Certified" {
+						return -1, -1, echo.NewHTTPError(http.StatusBadRequest, "Version "+version.Name+" of VSP "+v.Name+" is certified")
+					}
+					return i, j, nil
+				}
+			}
+		}
+	}
+	return -1, -1, echo.NewHTTPError(http.StatusNotFound, "Item Not Found")
+}
+
+// setVspDraft makes the version j of the VSP i a draft again, once its
+// model has changed
+func setVspDraft(i int, j int) {
+	vspList[i].Versions[j].RealStatus = "Draft"
+	vspList[i].Versions[j].State.Dirty = true
+}
+
+// manualVspComponent returns the indexes of the manual VSP of the route, of
+// its version and of its component, or the error to return
+func manualVspComponent(c echo.Context) (int, int, int, error) {
+	i, j, err := manualVspVersion(c)
+	if i < 0 {
+		return -1, -1, -1, err
+	}
+	componentID := c.Param("componentID")
+	for k, component := range vspList[i].Components {
+		if component.ID == componentID {
+			return i, j, k, nil
+		}
+	}
+	return -1, -1, -1, echo.NewHTTPError(http.StatusNotFound, "Component "+componentID+" not found")
+}
+
+// checkModelName checks that an entity of the model of a manual VSP has a
+// name, and that no other entity of the same kind has it
+func checkModelName(kind string, name string, others []string) error {
+	if strings.TrimSpace(name) == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, kind+" has no name")
+	}
+	for _, other := range others {
+		if strings.EqualFold(other, name) {
+			return echo.NewHTTPError(http.StatusConflict, kind+" "+name+" already exists")
+		}
+	}
+	return nil
+}
+
+// validateVspComponent checks a component of the VSP v, the componentID
+// one when it is updated
+func validateVspComponent(v Vsp, component *VspComponent, componentID string) error {
+	others := []string{}
+	for _, other := range v.Components {
+		if other.ID != componentID {
+			others = append(others, other.Name)
+		}
+	}
+	if err := checkModelName("Component", component.Name, others); err != nil {
+		return err
+	}
+	if component.DisplayName == "" {
+		component.DisplayName = component.Name
+	}
+	return nil
+}
+
+func getVspComponents(c echo.Context) error {
+	i, _, err := manualVspVersion(c)
+	if i < 0 {
+		return err
+	}
+	components := vspList[i].Components
+	return c.JSON(http.StatusOK, ModelList[VspComponent]{len(components), components})
+}
+
+func postVspComponent(c echo.Context) error {
+	i, j, err := manualVspVersion(c)
+	if i < 0 {
+		return err
+	}
+	component := new(VspComponent)
+	if err := c.Bind(component); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid component")
+	}
+	if err := validateVspComponent(vspList[i], component, ""); err != nil {
+		return err
+	}
+	component.ID = uuid.NewV4().String()
+	vspList[i].Components = append(vspList[i].Components, *component)
+	setVspDraft(i, j)
+	return c.JSON(http.StatusCreated, CreatedModelItem{ID: component.ID})
+}
+
+func getVspComponent(c echo.Context) error {
+	i, _, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	return c.JSON(http.StatusOK, vspList[i].Components[k])
+}
+
+func putVspComponent(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	component := new(VspComponent)
+	if err := c.Bind(component); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid component")
+	}
+	existing := vspList[i].Components[k]
+	if err := validateVspComponent(vspList[i], component, existing.ID); err != nil {
+		return err
+	}
+	component.ID = existing.ID
+	component.Nics = existing.Nics
+	component.ComputeFlavors = existing.ComputeFlavors
+	component.Images = existing.Images
+	vspList[i].Components[k] = *component
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+func deleteVspComponent(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	removeComponentAssociations(i, vspList[i].Components[k].ID, "")
+	components := vspList[i].Components
+	vspList[i].Components = append(components[:k:k], components[k+1:]...)
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+// vspNicIndex returns the index of the NIC of the route in the component k
+// of the VSP i, or the error to return
+func vspNicIndex(c echo.Context, i int, k int) (int, error) {
+	nicID := c.Param("nicID")
+	for n, nic := range vspList[i].Components[k].Nics {
+		if nic.ID == nicID {
+			return n, nil
+		}
+	}
+	return -1, echo.NewHTTPError(http.StatusNotFound, "NIC "+nicID+" not found")
+}
+
+// validateNic checks a NIC of a component, the nicID one when it is
+// updated. NICs are on external networks unless told otherwise.
+func validateNic(component VspComponent, nic *Nic, nicID string) error {
+	others := []string{}
+	for _, other := range component.Nics {
+		if other.ID != nicID {
+			others = append(others, other.Name)
+		}
+	}
+	if err := checkModelName("NIC", nic.Name, others); err != nil {
+		return err
+	}
+	if nic.NetworkType == "" {
+		nic.NetworkType = "External"
+	}
+	if nic.NetworkType != "External" && nic.NetworkType != "Internal" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid network type "+nic.NetworkType+" of NIC "+nic.Name)
+	}
+	return nil
+}
+
+func getVspComponentNics(c echo.Context) error {
+	i, _, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	nics := vspList[i].Components[k].Nics
+	return c.JSON(http.StatusOK, ModelList[Nic]{len(nics), nics})
+}
+
+func postVspComponentNic(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	nic := new(Nic)
+	if err := c.Bind(nic); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid NIC")
+	}
+	component := &vspList[i].Components[k]
+	if err := validateNic(*component, nic, ""); err != nil {
+		return err
+	}
+	nic.ID = uuid.NewV4().String()
+	component.Nics = append(component.Nics, *nic)
+	setVspDraft(i, j)
+	return c.JSON(http.StatusCreated, CreatedModelItem{ID: nic.ID})
+}
+
+func getVspComponentNic(c echo.Context) error {
+	i, _, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspNicIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	return c.JSON(http.StatusOK, vspList[i].Components[k].Nics[n])
+}
+
+func putVspComponentNic(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspNicIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	nic := new(Nic)
+	if err := c.Bind(nic); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid NIC")
+	}
+	component := &vspList[i].Components[k]
+	if err := validateNic(*component, nic, component.Nics[n].ID); err != nil {
+		return err
+	}
+	nic.ID = component.Nics[n].ID
+	component.Nics[n] = *nic
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+func deleteVspComponentNic(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspNicIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	component := &vspList[i].Components[k]
+	component.Nics = append(component.Nics[:n:n], component.Nics[n+1:]...)
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+// vspComputeFlavorIndex returns the index of the compute flavor of the route
+// in the component k of the VSP i, or the error to return
+func vspComputeFlavorIndex(c echo.Context, i int, k int) (int, error) {
+	computeFlavorID := c.Param("computeFlavorID")
+	for n, f := range vspList[i].Components[k].ComputeFlavors {
+		if f.ID == computeFlavorID {
+			return n, nil
+		}
+	}
+	return -1, echo.NewHTTPError(http.StatusNotFound, "Compute flavor "+computeFlavorID+" not found")
+}
+
+// validateComputeFlavor checks a compute flavor of a component, the
+// computeFlavorID one when it is updated
+func validateComputeFlavor(component VspComponent, f *ComputeFlavor, computeFlavorID string) error {
+	others := []string{}
+	for _, other := range component.ComputeFlavors {
+		if other.ID != computeFlavorID {
+			others = append(others, other.Name)
+		}
+	}
+	return checkModelName("Compute flavor", f.Name, others)
+}
+
+func getVspComponentComputeFlavors(c echo.Context) error {
+	i, _, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	flavors := vspList[i].Components[k].ComputeFlavors
+	return c.JSON(http.StatusOK, ModelList[ComputeFlavor]{len(flavors), flavors})
+}
+
+func postVspComponentComputeFlavor(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	f := new(ComputeFlavor)
+	if err := c.Bind(f); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid compute flavor")
+	}
+	component := &vspList[i].Components[k]
+	if err := validateComputeFlavor(*component, f, ""); err != nil {
+		return err
+	}
+	f.ID = uuid.NewV4().String()
+	component.ComputeFlavors = append(component.ComputeFlavors, *f)
+	setVspDraft(i, j)
+	return c.JSON(http.StatusCreated, CreatedModelItem{ID: f.ID})
+}
+
+func getVspComponentComputeFlavor(c echo.Context) error {
+	i, _, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspComputeFlavorIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	return c.JSON(http.StatusOK, vspList[i].Components[k].ComputeFlavors[n])
+}
+
+func putVspComponentComputeFlavor(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspComputeFlavorIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	f := new(ComputeFlavor)
+	if err := c.Bind(f); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid compute flavor")
+	}
+	component := &vspList[i].Components[k]
+	if err := validateComputeFlavor(*component, f, component.ComputeFlavors[n].ID); err != nil {
+		return err
+	}
+	f.ID = component.ComputeFlavors[n].ID
+	component.ComputeFlavors[n] = *f
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+func deleteVspComponentComputeFlavor(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspComputeFlavorIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	component := &vspList[i].Components[k]
+	removeComponentAssociations(i, component.ID, component.ComputeFlavors[n].ID)
+	component.ComputeFlavors = append(component.ComputeFlavors[:n:n], component.ComputeFlavors[n+1:]...)
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+// vspImageIndex returns the index of the image of the route in the
+// component k of the VSP i, or the error to return
+func vspImageIndex(c echo.Context, i int, k int) (int, error) {
+	imageID := c.Param("imageID")
+	for n, image := range vspList[i].Components[k].Images {
+		if image.ID == imageID {
+			return n, nil
+		}
+	}
+	return -1, echo.NewHTTPError(http.StatusNotFound, "Image "+imageID+" not found")
+}
+
+// validateImage checks an image of a component, the imageID one when it is
+// updated
+func validateImage(component VspComponent, image *Image, imageID string) error {
+	others := []string{}
+	for _, other := range component.Images {
+		if other.ID != imageID {
+			others = append(others, other.FileName)
+		}
+	}
+	return checkModelName("Image", image.FileName, others)
+}
+
+func getVspComponentImages(c echo.Context) error {
+	i, _, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	images := vspList[i].Components[k].Images
+	return c.JSON(http.StatusOK, ModelList[Image]{len(images), images})
+}
+
+func postVspComponentImage(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	image := new(Image)
+	if err := c.Bind(image); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid image")
+	}
+	component := &vspList[i].Components[k]
+	if err := validateImage(*component, image, ""); err != nil {
+		return err
+	}
+	image.ID = uuid.NewV4().String()
+	component.Images = append(component.Images, *image)
+	setVspDraft(i, j)
+	return c.JSON(http.StatusCreated, CreatedModelItem{ID: image.ID})
+}
+
+func getVspComponentImage(c echo.Context) error {
+	i, _, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspImageIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	return c.JSON(http.StatusOK, vspList[i].Components[k].Images[n])
+}
+
+func putVspComponentImage(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspImageIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	image := new(Image)
+	if err := c.Bind(image); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid image")
+	}
+	component := &vspList[i].Components[k]
+	if err := validateImage(*component, image, component.Images[n].ID); err != nil {
+		return err
+	}
+	image.ID = component.Images[n].ID
+	component.Images[n] = *image
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+func deleteVspComponentImage(c echo.Context) error {
+	i, j, k, err := manualVspComponent(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspImageIndex(c, i, k)
+	if n < 0 {
+		return err
+	}
+	component := &vspList[i].Components[k]
+	component.Images = append(component.Images[:n:n], component.Images[n+1:]...)
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+// vspDeploymentFlavorIndex returns the index of the deployment flavor of the
+// route in the VSP i, or the error to return
+func vspDeploymentFlavorIndex(c echo.Context, i int) (int, error) {
+	deploymentFlavorID := c.Param("deploymentFlavorID")
+	for n, f := range vspList[i].DeploymentFlavors {
+		if f.ID == deploymentFlavorID {
+			return n, nil
+		}
+	}
+	return -1, echo.NewHTTPError(http.StatusNotFound, "Deployment flavor "+deploymentFlavorID+" not found")
+}
+
+// validateDeploymentFlavor checks a deployment flavor of the VSP v, the
+// deploymentFlavorID one when it is updated
+func validateDeploymentFlavor(v Vsp, f *DeploymentFlavor, deploymentFlavorID string) error {
+	others := []string{}
+	for _, other := range v.DeploymentFlavors {
+		if other.ID != deploymentFlavorID {
+			others = append(others, other.Model)
+		}
+	}
+	if err := checkModelName("Deployment flavor", f.Model, others); err != nil {
+		return err
+	}
+	if err := validateComputeAssociations(v, f); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+func getVspDeploymentFlavors(c echo.Context) error {
+	i, _, err := manualVspVersion(c)
+	if i < 0 {
+		return err
+	}
+	flavors := vspList[i].DeploymentFlavors
+	return c.JSON(http.StatusOK, ModelList[DeploymentFlavor]{len(flavors), flavors})
+}
+
+func postVspDeploymentFlavor(c echo.Context) error {
+	i, j, err := manualVspVersion(c)
+	if i < 0 {
+		return err
+	}
+	f := new(DeploymentFlavor)
+	if err := c.Bind(f); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid deployment flavor")
+	}
+	if err := validateDeploymentFlavor(vspList[i], f, ""); err != nil {
+		return err
+	}
+	f.ID = uuid.NewV4().String()
+	vspList[i].DeploymentFlavors = append(vspList[i].DeploymentFlavors, *f)
+	setVspDraft(i, j)
+	return c.JSON(http.StatusCreated, CreatedModelItem{ID: f.ID})
+}
+
+func getVspDeploymentFlavor(c echo.Context) error {
+	i, _, err := manualVspVersion(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspDeploymentFlavorIndex(c, i)
+	if n < 0 {
+		return err
+	}
+	return c.JSON(http.StatusOK, vspList[i].DeploymentFlavors[n])
+}
+
+func putVspDeploymentFlavor(c echo.Context) error {
+	i, j, err := manualVspVersion(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspDeploymentFlavorIndex(c, i)
+	if n < 0 {
+		return err
+	}
+	f := new(DeploymentFlavor)
+	if err := c.Bind(f); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid deployment flavor")
+	}
+	if err := validateDeploymentFlavor(vspList[i], f, vspList[i].DeploymentFlavors[n].ID); err != nil {
+		return err
+	}
+	f.ID = vspList[i].DeploymentFlavors[n].ID
+	vspList[i].DeploymentFlavors[n] = *f
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+func deleteVspDeploymentFlavor(c echo.Context) error {
+	i, j, err := manualVspVersion(c)
+	if i < 0 {
+		return err
+	}
+	n, err := vspDeploymentFlavorIndex(c, i)
+	if n < 0 {
+		return err
+	}
+	flavors := vspList[i].DeploymentFlavors
+	vspList[i].DeploymentFlavors = append(flavors[:n:n], flavors[n+1:]...)
+	setVspDraft(i, j)
+	return c.String(http.StatusOK, "{}")
+}
+
+// validateComputeAssociations checks that a deployment flavor associates
+// existing compute flavors to the components of the VSP, one per component
+func validateComputeAssociations(v Vsp, f *DeploymentFlavor) error {
+	if f.ComponentComputeAssociations == nil {
+		f.ComponentComputeAssociations = []ComponentComputeAssociation{}
+	}
+	associated := map[string]bool{}
+	for _, association := range f.ComponentComputeAssociations {
+		component, found := findVspComponent(v, association.ComponentID)
+		if !found {
+			return errors.New("Component " + association.ComponentID + " of deployment flavor " + f.Model + " not found")
+		}
+		if associated[association.ComponentID] {
+			return errors.New("Component " + component.Name + " has several compute flavors in deployment flavor " + f.Model)
+		}
+		associated[association.ComponentID] = true
+		if _, found := findComputeFlavor(component, association.ComputeFlavorID); !found {
+			return errors.New("Compute flavor " + association.ComputeFlavorID + " of component " + component.Name + " not found")
+		}
+	}
+	return nil
+}
+
+// removeComponentAssociations removes from the deployment flavors of the
+// VSP i the associations of a deleted component, or of one of its compute
+// flavors if computeFlavorID is set
+func removeComponentAssociations(i int, componentID string, computeFlavorID string) {
+	for n, f := range vspList[i].DeploymentFlavors {
+		kept := []ComponentComputeAssociation{}
+		for _, association := range f.ComponentComputeAssociations {
+			if association.ComponentID != componentID || (computeFlavorID != "" && association.ComputeFlavorID != computeFlavorID) {
+				kept = append(kept, association)
+			}
+		}
+		vspList[i].DeploymentFlavors[n].ComponentComputeAssociations = kept
+	}
+}
+
+func findVspComponent(v Vsp, componentID string) (VspComponent, bool) {
+	for _, component := range v.Components {
+		if component.ID == componentID {
+			return component, true
+		}
+	}
+	return VspComponent{}, false
+}
+
+func findComputeFlavor(component VspComponent, computeFlavorID string) (ComputeFlavor, bool) {
+	for _, f := range component.ComputeFlavors {
+		if f.ID == computeFlavorID {
+			return f, true
+		}
+	}
+	return ComputeFlavor{}, false
+}
+
+// heatName turns a name of the model into a Heat resource or parameter name
+func heatName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "_")
+}
+
+// componentComputeFlavor is the compute flavor of a component in the first
+// deployment flavor associating one to it, or its first compute flavor
+func componentComputeFlavor(v Vsp, component VspComponent) ComputeFlavor {
+	for _, f := range v.DeploymentFlavors {
+		for _, association := range f.ComponentComputeAssociations {
+			if association.ComponentID == component.ID {
+				if flavor, found := findComputeFlavor(component, association.ComputeFlavorID); found {
+					return flavor
+				}
+			}
+		}
+	}
+	return component.ComputeFlavors[0]
+}
+
+// componentTemplate generates the Heat template of a component, a server
+// with a port per NIC, and its env file giving the image, the flavor and
+// the networks of the model
+func componentTemplate(v Vsp, component VspComponent) ([]byte, []byte) {
+	name := heatName(component.Name)
+	description := component.Description
+	if description == "" {
+		description = "Component " + component.Name + " of " + v.Name
+	}
+	parameters := map[string]interface{}{
+		name + "_image_name":  map[string]string{"type": "string", "description": "Image of " + component.Name},
+		name + "_flavor_name": map[string]string{"type": "string", "description": "Flavor of " + component.Name},
+	}
+	env := map[string]interface{}{
+		name + "_image_name":  component.Images[0].FileName,
+		name + "_flavor_name": componentComputeFlavor(v, component).Name,
+	}
+	resources := map[string]interface{}{}
+	networks := []interface{}{}
+	for _, nic := range component.Nics {
+		port := name + "_" + heatName(nic.Name) + "_port"
+		network := name + "_" + heatName(nic.Name) + "_net_name"
+		parameters[network] = map[string]string{"type": "string", "description": nic.NetworkType + " network of NIC " + nic.Name}
+		if nic.NetworkName != "" {
+			env[network] = nic.NetworkName
+		}
+		resources[port] = map[string]interface{}{
+			"type":       "OS::Neutron::Port",
+			"properties": map[string]interface{}{"network": map[string]string{"get_param": network}},
+		}
+		networks = append(networks, map[string]interface{}{"port": map[string]string{"get_resource": port}})
+	}
+	resources[name+"_server"] = map[string]interface{}{
+		"type": "OS::Nova::Server",
+		"properties": map[string]interface{}{
+			"name":     name,
+			"image":    map[string]string{"get_param": name + "_image_name"},
+			"flavor":   map[string]string{"get_param": name + "_flavor_name"},
+			"networks": networks,
+		},
+	}
+	template, _ := yaml.Marshal(map[string]interface{}{
+		"heat_template_version": "2013-05-23",
+		"description":           description,
+		"parameters":            parameters,
+		"resources":             resources,
+	})
+	envFile, _ := yaml.Marshal(map[string]interface{}{"parameters": env})
+	return template, envFile
+}
+
+// manualPackage generates the Heat package of a manual VSP from its model:
+// a module per component, the first being the base one. Every component
+// needs an image and a compute flavor.
+func manualPackage(v Vsp) (*OnboardedPackage, error) {
+	if len(v.Components) == 0 {
+		return nil, errors.New("VSP " + v.Name + " has no component")
+	}
+	p := &OnboardedPackage{
+		Files:        map[string][]byte{},
+		ResourceType: "VF",
+		Manifest: Manifest{
+			Name:        v.Name,
+			Description: v.Description,
+			Version:     "1.0",
+			Data:        []ManifestFile{},
+		},
+	}
+	for n, component := range v.Components {
+		if len(component.Images) == 0 {
+			return nil, errors.New("Component " + component.Name + " has no image")
+		}
+		if len(component.ComputeFlavors) == 0 {
+			return nil, errors.New("Component " + component.Name + " has no compute flavor")
+		}
+		name := heatName(component.Name)
+		if n == 0 {
+			name = "base_" + name
+		}
+		if _, found := p.Files[name+".yaml"]; found {
+			return nil, errors.New("Component " + component.Name + " has the Heat name of another component")
+		}
+		template, env := componentTemplate(v, component)
+		p.Files[name+".yaml"] = template
+		p.Files[name+".env"] = env
+		p.Manifest.Data = append(p.Manifest.Data, ManifestFile{
+			File:   name + ".yaml",
+			Type:   "HEAT",
+			IsBase: n == 0,
+			Data:   []ManifestFile{{File: name + ".env", Type: "HEAT_ENV"}},
+		})
+	}
+	p.Files["MANIFEST.json"], _ = json.MarshalIndent(p.Manifest, "", "  ")
+	return p, nil
+}
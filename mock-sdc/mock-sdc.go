@@ -37,35 +37,151 @@ func main() {
 	e.PUT("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/actions", updateVspVersion)
 	e.POST("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/orchestration-template-candidate", uploadArtifacts)
 	e.PUT("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/orchestration-template-candidate/process", validateArtifacts)
-	e.GET("/sdc1/feProxy/rest/v1/followed", getAllResources)
-	e.GET("/sdc1/feProxy/rest/v1/screen", getAllResources)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components", getVspComponents)
+	e.POST("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components", postVspComponent)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID", getVspComponent)
+	e.PUT("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID", putVspComponent)
+	e.DELETE("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID", deleteVspComponent)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/nics", getVspComponentNics)
+	e.POST("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/nics", postVspComponentNic)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/nics/:nicID", getVspComponentNic)
+	e.PUT("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/nics/:nicID", putVspComponentNic)
+	e.DELETE("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/nics/:nicID", deleteVspComponentNic)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/compute-flavors", getVspComponentComputeFlavors)
+	e.POST("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/compute-flavors", postVspComponentComputeFlavor)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/compute-flavors/:computeFlavorID", getVspComponentComputeFlavor)
+	e.PUT("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/compute-flavors/:computeFlavorID", putVspComponentComputeFlavor)
+	e.DELETE("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/compute-flavors/:computeFlavorID", deleteVspComponentComputeFlavor)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/images", getVspComponentImages)
+	e.POST("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/images", postVspComponentImage)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/images/:imageID", getVspComponentImage)
+	e.PUT("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/images/:imageID", putVspComponentImage)
+	e.DELETE("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/components/:componentID/images/:imageID", deleteVspComponentImage)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/deployment-flavors", getVspDeploymentFlavors)
+	e.POST("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/deployment-flavors", postVspDeploymentFlavor)
+	e.GET("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/deployment-flavors/:deploymentFlavorID", getVspDeploymentFlavor)
+	e.PUT("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/deployment-flavors/:deploymentFlavorID", putVspDeploymentFlavor)
+	e.DELETE("/sdc1/feProxy/onboarding-api/v1.0/vendor-software-products/:vspID/versions/:versionID/deployment-flavors/:deploymentFlavorID", deleteVspDeploymentFlavor)
+	e.GET("/sdc1/feProxy/rest/v1/followed", getFollowed)
+	e.GET("/sdc1/feProxy/rest/v1/screen", getScreen)
 	e.GET("/sdc/v1/catalog/resources", getResources)
 	e.GET("/sdc/v1/catalog/services", getServices)
+	e.GET("/sdc/v1/catalog/resources/:uuid/toscaModel", getToscaModel)
+	e.GET("/sdc/v1/catalog/services/:uuid/toscaModel", getToscaModel)
+	e.GET("/sdc/v1/catalog/resources/:uuid/metadata", getComponentMetadata)
+	e.GET("/sdc/v1/catalog/services/:uuid/metadata", getComponentMetadata)
+	e.GET("/sdc/v1/catalog/resources/:uuid/artifacts/:artifactUUID", getComponentArtifact)
+	e.GET("/sdc/v1/catalog/services/:uuid/artifacts/:artifactUUID", getComponentArtifact)
+	e.GET("/sdc/v1/catalog/services/:uuid/resourceInstances/:instanceName/artifacts/:artifactUUID", getComponentInstanceArtifact)
+	e.POST("/sdc/v1/catalog/resources", postExternalComponent)
+	e.POST("/sdc/v1/catalog/services", postExternalComponent)
+	e.POST("/sdc/v1/catalog/resources/:uuid/lifecycleState/:operation", postExternalLifecycleState)
+	e.POST("/sdc/v1/catalog/services/:uuid/lifecycleState/:operation", postExternalLifecycleState)
+	e.POST("/sdc/v1/catalog/resources/:uuid/artifacts", postExternalArtifact)
+	e.POST("/sdc/v1/catalog/services/:uuid/artifacts", postExternalArtifact)
+	e.POST("/sdc/v1/catalog/resources/:uuid/artifacts/:artifactUUID", updateExternalArtifact)
+	e.POST("/sdc/v1/catalog/services/:uuid/artifacts/:artifactUUID", updateExternalArtifact)
+	e.DELETE("/sdc/v1/catalog/resources/:uuid/artifacts/:artifactUUID", deleteExternalArtifact)
+	e.DELETE("/sdc/v1/catalog/services/:uuid/artifacts/:artifactUUID", deleteExternalArtifact)
+	e.POST("/sdc/v1/catalog/services/:uuid/resourceInstances/:instanceName/artifacts", postExternalInstanceArtifact)
 	e.GET("/sdc/v1/artifactTypes", getArtifactTypes)
+	e.GET("/sdc1/feProxy/rest/v1/artifactTypes", getArtifactTypesConfiguration)
 	e.GET("/sdc/v1/distributionKafkaData", distributionKafkaData)
 	e.POST("/sdc/v1/registerForDistribution", registerForDistribution)
 	e.POST("/sdc/v1/unRegisterForDistribution", unRegisterForDistribution)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/resources", postResources)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/lifecycleState/:action", postResourceAction)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/services", postResources)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/serviceFromCsar", postServiceFromCsar)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/resourceInstance", postAddResourceToService)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/lifecycleState/:action", postResourceAction)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/distribution-state/:action", postResourceAction)
-	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/distribution/PROD/:action", postResourceAction)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/distribution/:env/:action", postResourceAction)
 	e.GET("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/distribution", getDistribution)
 	e.GET("/sdc1/feProxy/rest/v1/catalog/services/distribution/:distributionID", getDistributionList)
 	e.GET("/sdc1/feProxy/rest/v1/catalog/services/:resourceID", getServiceUniqueIdentifier)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/resourceInstance/associate", postAssociate)
+	e.PUT("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/resourceInstance/dissociate", putDissociate)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/resourceInstance/:vfID/artifacts", uploadTcaArtifact)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/resourceInstance/:instanceID", postComponentInstance)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/resourceInstance/:instanceID/changeVersion", postChangeVersion)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/resourceInstance/:instanceID/properties", postComponentInstanceProperties)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/artifacts", postResourceArtifact)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/artifacts", postResourceArtifact)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/artifacts/:artifactID", updateResourceArtifact)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/artifacts/:artifactID", updateResourceArtifact)
+	e.DELETE("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/artifacts/:artifactID", deleteResourceArtifact)
+	e.DELETE("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/artifacts/:artifactID", deleteResourceArtifact)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/artifacts/:artifactID", downloadResourceArtifact)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/artifacts/:artifactID", downloadResourceArtifact)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/properties", postResourceProperties)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/properties", postResourceProperties)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/properties/:propertyID", getResourceProperty)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/properties/:propertyID", getResourceProperty)
+	e.PUT("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/properties/:propertyID", putResourceProperty)
+	e.PUT("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/properties/:propertyID", putResourceProperty)
+	e.DELETE("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/properties/:propertyID", deleteResourceProperty)
+	e.DELETE("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/properties/:propertyID", deleteResourceProperty)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/groups/:groupID", getResourceGroup)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/groups/:groupID", getResourceGroup)
+	e.PUT("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/groups/:groupID/properties", putGroupProperties)
+	e.PUT("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/groups/:groupID/properties", putGroupProperties)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/create/inputs", postResourceInputs)
 	e.POST("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/create/inputs", postResourceInputs)
 	e.GET("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/filteredDataByParams", getResourcefilteredData)
 	e.GET("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/filteredDataByParams", getResourcefilteredData)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/archive", postArchive)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/archive", postArchive)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID/restore", postRestore)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/services/:resourceID/restore", postRestore)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/archive", getArchive)
+	e.GET("/sdc1/feProxy/rest/v1/dataTypes", getDataTypes)
+	e.GET("/sdc1/feProxy/rest/v1/capabilityTypes", getCapabilityTypes)
+	e.GET("/sdc1/feProxy/rest/v1/relationshipTypes", getRelationshipTypes)
+	e.GET("/sdc1/feProxy/rest/v1/policyTypes", getPolicyTypes)
+	e.GET("/sdc1/feProxy/rest/v1/groupTypes", getGroupTypes)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/model", getModels)
+	e.POST("/sdc1/feProxy/rest/v1/catalog/model", postModel)
+	e.PUT("/sdc1/feProxy/rest/v1/catalog/model/imports", putModelImports)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/model/:modelName", getModel)
+	e.GET("/sdc1/feProxy/rest/v1/catalog/model/:modelName/imports", getModelImports)
+	e.DELETE("/sdc1/feProxy/rest/v1/catalog/model/:modelName", deleteModel)
+	e.DELETE("/sdc1/feProxy/rest/v1/catalog/resources/:resourceID", deleteComponent)
+	e.DELETE("/sdc1/feProxy/rest/v1/catalog/services/:resourceID", deleteComponent)
 	e.GET("/sdc1/feProxy/rest/v1/setup/ui", getCategories)
+	e.GET("/sdc1/feProxy/rest/v1/categories/:componentType", getComponentCategories)
+	e.POST("/sdc1/feProxy/rest/v1/category/:componentType", postCategory)
+	e.POST("/sdc1/feProxy/rest/v1/category/:componentType/:categoryID/subCategory", postSubCategory)
+	e.GET("/sdc2/rest/v1/user/admins", getAdminUsers)
+	e.GET("/sdc2/rest/v1/user/users", getUsers)
+	e.GET("/sdc2/rest/v1/user/authorize", getAuthorizedUser)
+	e.POST("/sdc2/rest/v1/user", postUser)
+	e.GET("/sdc2/rest/v1/user/:userID", getUser)
+	e.DELETE("/sdc2/rest/v1/user/:userID", deleteUser)
+	e.GET("/sdc2/rest/v1/user/:userID/role", getUserRole)
+	e.POST("/sdc2/rest/v1/user/:userID/role", postUserRole)
 	e.POST("/reset", reset)
+	if err := loadErrorCatalog(); err != nil {
+		e.Logger.Fatal(err)
+	}
+	if err := loadArtifactTypes(); err != nil {
+		e.Logger.Fatal(err)
+	}
+	if err := generateInitialCategories(); err != nil {
+		e.Logger.Fatal(err)
+	}
+	if err := loadModels(); err != nil {
+		e.Logger.Fatal(err)
+	}
+	if !userIDRequired() {
+		e.Logger.Warn("USER_ID_REQUIRED is not set: requests without USER_ID are not checked")
+	}
+	generateInitialUserList()
 	generateInitialVendorList()
 	generateInitialVspList()
-	generateInitialResourceList()
+	if err := generateInitialResourceList(); err != nil {
+		e.Logger.Fatal(err)
+	}
 	generateDistributionStatusList()
 	e.Logger.Fatal(e.Start(":30206"))
 }
@@ -0,0 +1,504 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Model is a TOSCA model of the catalog, such as ETSI SOL001. Components
+// without model belong to the default ONAP model. Types holds the types of
+// its imports, parsed when they are loaded or replaced.
+type Model struct {
+	Name        string                `json:"name" yaml:"name"`
+	DerivedFrom string                `json:"derivedFrom,omitempty" yaml:"derivedFrom"`
+	ModelType   string                `json:"modelType" yaml:"modelType"`
+	Imports     map[string][]byte     `json:"-" yaml:"-"`
+	Types       *ToscaTypeDefinitions `json:"-" yaml:"-"`
+}
+
+// ModelDefinition is a model of config/models/models.yaml, with the files of
+// its imports
+type ModelDefinition struct {
+	Model   `yaml:",inline"`
+	Imports []string `yaml:"imports"`
+}
+
+// ModelConfiguration is the content of config/models/models.yaml
+type ModelConfiguration struct {
+	DefaultImports []string          `yaml:"defaultImports"`
+	Models         []ModelDefinition `yaml:"models"`
+}
+
+// ToscaCapabilityDefinition describes a capability of a TOSCA node type
+type ToscaCapabilityDefinition struct {
+	Type string `yaml:"type"`
+}
+
+// ToscaRequirementDefinition describes a requirement of a TOSCA node type
+type ToscaRequirementDefinition struct {
+	Capability   string `yaml:"capability"`
+	Node         string `yaml:"node"`
+	Relationship string `yaml:"relationship"`
+}
+
+// ToscaNodeType describes a TOSCA node type
+type ToscaNodeType struct {
+	DerivedFrom  string                                  `yaml:"derived_from"`
+	Description  string                                  `yaml:"description"`
+	Metadata     map[string]string                       `yaml:"metadata"`
+	Properties   map[string]ToscaParameter               `yaml:"properties"`
+	Capabilities map[string]ToscaCapabilityDefinition    `yaml:"capabilities"`
+	Requirements []map[string]ToscaRequirementDefinition `yaml:"requirements"`
+}
+
+// ToscaTypeDefinitions is the content of a TOSCA file imported by a model
+type ToscaTypeDefinitions struct {
+	DataTypes         map[string]ToscaType     `yaml:"data_types"`
+	CapabilityTypes   map[string]ToscaType     `yaml:"capability_types"`
+	RelationshipTypes map[string]ToscaType     `yaml:"relationship_types"`
+	PolicyTypes       map[string]ToscaType     `yaml:"policy_types"`
+	GroupTypes        map[string]ToscaType     `yaml:"group_types"`
+	NodeTypes         map[string]ToscaNodeType `yaml:"node_types"`
+}
+
+var modelList []Model
+
+// defaultModel holds the imports of the default ONAP model
+var defaultModel Model
+
+// loadModels reads the bundled models, or the ones of the MODELS_DIR
+// directory
+func loadModels() error {
+	data, err := readConfigurationFile("MODELS_DIR", "models", "models.yaml")
+	if err != nil {
+		return err
+	}
+	configuration := new(ModelConfiguration)
+	if err := yaml.Unmarshal(data, configuration); err != nil {
+		return err
+	}
+	defaultModel = Model{ModelType: "NORMATIVE", Imports: map[string][]byte{}}
+	for _, name := range configuration.DefaultImports {
+		if defaultModel.Imports[name], err = readConfigurationFile("MODELS_DIR", "models", name); err != nil {
+			return err
+		}
+	}
+	if err := defaultModel.parseTypes(); err != nil {
+		return errors.New("default model: " + err.Error())
+	}
+	modelList = nil
+	for _, definition := range configuration.Models {
+		model := definition.Model
+		model.Imports = map[string][]byte{}
+		for _, name := range definition.Imports {
+			if model.Imports[name], err = readConfigurationFile("MODELS_DIR", "models", name); err != nil {
+				return err
+			}
+		}
+		if err := model.parseTypes(); err != nil {
+			return errors.New("model " + model.Name + ": " + err.Error())
+		}
+		modelList = append(modelList, model)
+	}
+	return nil
+}
+
+func findModelIndex(name string) int {
+	for i, m := range modelList {
+		if m.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// findModel returns a model by name, the default one for an empty name
+func findModel(name string) (Model, bool) {
+	if name == "" {
+		return defaultModel, true
+	}
+	if i := findModelIndex(name); i >= 0 {
+		return modelList[i], true
+	}
+	return Model{}, false
+}
+
+func newTypeDefinitions() *ToscaTypeDefinitions {
+	return &ToscaTypeDefinitions{
+		DataTypes:         map[string]ToscaType{},
+		CapabilityTypes:   map[string]ToscaType{},
+		RelationshipTypes: map[string]ToscaType{},
+		PolicyTypes:       map[string]ToscaType{},
+		GroupTypes:        map[string]ToscaType{},
+		NodeTypes:         map[string]ToscaNodeType{},
+	}
+}
+
+// parseTypes reads and merges the types of the imports of a model
+func (m *Model) parseTypes() error {
+	types := newTypeDefinitions()
+	for _, file := range sortedKeys(m.Imports) {
+		if !strings.HasSuffix(file, ".yaml") && !strings.HasSuffix(file, ".yml") {
+			continue
+		}
+		definitions := new(ToscaTypeDefinitions)
+		if err := yaml.Unmarshal(m.Imports[file], definitions); err != nil {
+			return errors.New("invalid import " + file + ": " + err.Error())
+		}
+		mergeTypeDefinitions(types, definitions)
+	}
+	m.Types = types
+	return nil
+}
+
+func mergeTypes[V any](merged map[string]V, types map[string]V) {
+	for name, t := range types {
+		merged[name] = t
+	}
+}
+
+func mergeTypeDefinitions(merged *ToscaTypeDefinitions, definitions *ToscaTypeDefinitions) {
+	mergeTypes(merged.DataTypes, definitions.DataTypes)
+	mergeTypes(merged.CapabilityTypes, definitions.CapabilityTypes)
+	mergeTypes(merged.RelationshipTypes, definitions.RelationshipTypes)
+	mergeTypes(merged.PolicyTypes, definitions.PolicyTypes)
+	mergeTypes(merged.GroupTypes, definitions.GroupTypes)
+	mergeTypes(merged.NodeTypes, definitions.NodeTypes)
+}
+
+// modelTypes returns the types of a model and of the models it derives
+// from, merging the types parsed when their imports were loaded
+func modelTypes(name string) *ToscaTypeDefinitions {
+	chain := []Model{}
+	m, found := findModel(name)
+	for found {
+		chain = append([]Model{m}, chain...)
+		if m.DerivedFrom == "" {
+			break
+		}
+		m, found = findModel(m.DerivedFrom)
+	}
+	types := newTypeDefinitions()
+	for _, m := range chain {
+		if m.Types != nil {
+			mergeTypeDefinitions(types, m.Types)
+		}
+	}
+	return types
+}
+
+// normativeName is the name of the resource of a normative node type, its
+// metadata name or the type without namespaces, "tosca.nodes.nfv.Vdu.Compute"
+// becoming "Vdu.Compute"
+func normativeName(typeName string, nodeType ToscaNodeType) string {
+	if name := nodeType.Metadata["name"]; name != "" {
+		return name
+	}
+	name := typeName
+	if _, rest, found := strings.Cut(name, "nodes."); found {
+		name = rest
+	}
+	if namespace, rest, found := strings.Cut(name, "."); found && strings.ToLower(namespace) == namespace {
+		name = rest
+	}
+	return name
+}
+
+// typeProperties turns the property definitions of a TOSCA type into the
+// properties of its SDC counterpart
+func typeProperties(parentID string, definitions map[string]ToscaParameter) []Property {
+	properties := []Property{}
+	for _, name := range sortedKeys(definitions) {
+		definition := definitions[name]
+		property := Property{
+			Name:           name,
+			Type:           definition.Type,
+			UniqueID:       parentID + "." + name,
+			ParentUniqueID: parentID,
+			Description:    definition.Description,
+			Required:       definition.Required == nil || *definition.Required,
+		}
+		if definition.Default != nil {
+			property.Value = toscaValue(definition.Default)
+		}
+		if definition.EntrySchema != nil {
+			property.Schema = &PropertySchema{Property: SchemaProperty{Type: definition.EntrySchema.Type}}
+		}
+		properties = append(properties, property)
+	}
+	return properties
+}
+
+// normativeTypes returns the resources of the node types of the imports of
+// a model
+func normativeTypes(m Model) []Resource {
+	resources := []Resource{}
+	if m.Types == nil {
+		return resources
+	}
+	for _, typeName := range sortedKeys(m.Types.NodeTypes) {
+		nodeType := m.Types.NodeTypes[typeName]
+		r := Resource{
+			ID:                nodeType.Metadata["uuid"],
+			InvariantID:       nodeType.Metadata["invariantUUID"],
+			UniqueID:          nodeType.Metadata["uniqueId"],
+			ComponentType:     "RESOURCE",
+			ResourceType:      nodeType.Metadata["resourceType"],
+			Name:              normativeName(typeName, nodeType),
+			Category:          nodeType.Metadata["category"],
+			SubCategory:       nodeType.Metadata["subcategory"],
+			Description:       nodeType.Description,
+			Icon:              "defaulticon",
+			LastUpdaterUserID: "jh0003",
+			LifecycleState:    "CERTIFIED",
+			Version:           "1.0",
+			Model:             m.Name,
+			Normative:         true,
+			ToscaResourceName: typeName,
+		}
+		if r.ID == "" {
+			r.ID = uuid.NewV4().String()
+		}
+		if r.InvariantID == "" {
+			r.InvariantID = uuid.NewV4().String()
+		}
+		if r.UniqueID == "" {
+			r.UniqueID = uuid.NewV4().String()
+		}
+		if r.ResourceType == "" {
+			r.ResourceType = "VFC"
+		}
+		if r.Category == "" {
+			r.Category, r.SubCategory = "Generic", "Abstract"
+		}
+		r.Tags = []string{r.Name}
+		r.ToscaModelURL = "/sdc/v1/catalog/resources/" + r.ID + "/toscaModel"
+		r.Properties = typeProperties(r.UniqueID, nodeType.Properties)
+		initResourceCollections(&r)
+		resources = append(resources, r)
+	}
+	return resources
+}
+
+// addNormativeTypes adds to the catalog the normative resources of a model
+// it does not have yet
+func addNormativeTypes(m Model) {
+	for _, r := range normativeTypes(m) {
+		found := false
+		for _, other := range resourceList {
+			found = found || (other.Normative && other.Model == m.Name && other.ToscaResourceName == r.ToscaResourceName)
+		}
+		if !found {
+			resourceList = append(resourceList, r)
+		}
+	}
+}
+
+// inModel tells whether the component r belongs to the model of the model
+// query parameter. An empty or absent model selects the default one.
+func inModel(c echo.Context, r Resource) bool {
+	return c.QueryParam("model") == r.Model
+}
+
+// modelImports reads the files of the modelImportsZip part of a request
+func modelImports(c echo.Context) (map[string][]byte, int, *SdcError) {
+	file, err := c.FormFile("modelImportsZip")
+	if err != nil {
+		status, sdcError := newSdcError("MODEL_IMPORTS_IS_EMPTY")
+		return nil, status, sdcError
+	}
+	src, err := file.Open()
+	if err != nil {
+		status, sdcError := newSdcError("COULD_NOT_READ_MODEL_IMPORTS")
+		return nil, status, sdcError
+	}
+	defer src.Close()
+	payload, err := io.ReadAll(src)
+	if err != nil {
+		status, sdcError := newSdcError("COULD_NOT_READ_MODEL_IMPORTS")
+		return nil, status, sdcError
+	}
+	imports, err := readZip(payload)
+	if err != nil {
+		status, sdcError := newSdcError("COULD_NOT_READ_MODEL_IMPORTS")
+		return nil, status, sdcError
+	}
+	if len(imports) == 0 {
+		status, sdcError := newSdcError("MODEL_IMPORTS_IS_EMPTY")
+		return nil, status, sdcError
+	}
+	return imports, 0, nil
+}
+
+func getModels(c echo.Context) error {
+	models := []Model{}
+	for _, m := range modelList {
+		if modelType := c.QueryParam("modelType"); modelType == "" || strings.EqualFold(modelType, m.ModelType) {
+			models = append(models, m)
+		}
+	}
+	return c.JSON(http.StatusOK, models)
+}
+
+func getModel(c echo.Context) error {
+	i := findModelIndex(c.Param("modelName"))
+	if i < 0 {
+		status, sdcError := newSdcError("MODEL_NOT_FOUND", c.Param("modelName"))
+		return c.JSON(status, sdcError)
+	}
+	return c.JSON(http.StatusOK, modelList[i])
+}
+
+// postModel creates a model from the JSON of the model part and the TOSCA
+// files of the modelImportsZip part
+func postModel(c echo.Context) error {
+	if _, ok, err := authorizeUser(c, "ADMIN"); !ok {
+		return err
+	}
+	model := new(Model)
+	if err := json.Unmarshal([]byte(c.FormValue("model")), model); err != nil || strings.TrimSpace(model.Name) == "" {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	if findModelIndex(model.Name) >= 0 {
+		status, sdcError := newSdcError("MODEL_ALREADY_EXISTS", model.Name)
+		return c.JSON(status, sdcError)
+	}
+	if model.DerivedFrom != "" && findModelIndex(model.DerivedFrom) < 0 {
+		status, sdcError := newSdcError("MODEL_NOT_FOUND", model.DerivedFrom)
+		return c.JSON(status, sdcError)
+	}
+	if model.ModelType == "" {
+		model.ModelType = "NORMATIVE"
+	}
+	imports, status, sdcError := modelImports(c)
+	if sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	model.Imports = imports
+	if err := model.parseTypes(); err != nil {
+		status, sdcError := newSdcError("COULD_NOT_READ_MODEL_IMPORTS")
+		return c.JSON(status, sdcError)
+	}
+	modelList = append(modelList, *model)
+	addNormativeTypes(*model)
+	return c.JSON(http.StatusCreated, model)
+}
+
+// putModelImports replaces the imports of the model of the modelName part:
+// the normative resources of the node types they no longer define are
+// removed, and the ones of their new node types added
+func putModelImports(c echo.Context) error {
+	if _, ok, err := authorizeUser(c, "ADMIN"); !ok {
+		return err
+	}
+	i := findModelIndex(c.FormValue("modelName"))
+	if i < 0 {
+		status, sdcError := newSdcError("MODEL_NOT_FOUND", c.FormValue("modelName"))
+		return c.JSON(status, sdcError)
+	}
+	imports, status, sdcError := modelImports(c)
+	if sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	model := modelList[i]
+	model.Imports = imports
+	if err := model.parseTypes(); err != nil {
+		status, sdcError := newSdcError("COULD_NOT_READ_MODEL_IMPORTS")
+		return c.JSON(status, sdcError)
+	}
+	defined := map[string]bool{}
+	for typeName := range model.Types.NodeTypes {
+		defined[typeName] = true
+	}
+	// the normative resources of the node types dropped by the new imports
+	// are removed, unless a service still uses them
+	kept := []Resource{}
+	for _, r := range resourceList {
+		if !r.Normative || r.Model != model.Name || defined[r.ToscaResourceName] {
+			kept = append(kept, r)
+			continue
+		}
+		if users := componentUsers(r); len(users) != 0 {
+			status, sdcError := newSdcError("MODEL_IN_USE", model.Name, "service "+users[0].Name)
+			return c.JSON(status, sdcError)
+		}
+	}
+	resourceList = kept
+	modelList[i] = model
+	addNormativeTypes(model)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getModelImports returns the imports of a model as a zip archive
+func getModelImports(c echo.Context) error {
+	i := findModelIndex(c.Param("modelName"))
+	if i < 0 {
+		status, sdcError := newSdcError("MODEL_NOT_FOUND", c.Param("modelName"))
+		return c.JSON(status, sdcError)
+	}
+	return c.Blob(http.StatusOK, "application/zip", writeZip(modelList[i].Imports))
+}
+
+// deleteModel removes a model and its normative resources, unless a
+// component or another model uses it
+func deleteModel(c echo.Context) error {
+	if _, ok, err := authorizeUser(c, "ADMIN"); !ok {
+		return err
+	}
+	name := c.Param("modelName")
+	if findModelIndex(name) < 0 {
+		status, sdcError := newSdcError("MODEL_NOT_FOUND", name)
+		return c.JSON(status, sdcError)
+	}
+	for _, m := range modelList {
+		if m.DerivedFrom == name {
+			status, sdcError := newSdcError("MODEL_IN_USE", name, "model "+m.Name)
+			return c.JSON(status, sdcError)
+		}
+	}
+	for _, r := range resourceList {
+		if r.Model == name && !r.Normative {
+			status, sdcError := newSdcError("MODEL_IN_USE", name, strings.ToLower(componentKind(r))+" "+r.Name)
+			return c.JSON(status, sdcError)
+		}
+	}
+	kept := []Resource{}
+	for _, r := range resourceList {
+		if r.Model != name {
+			kept = append(kept, r)
+		}
+	}
+	resourceList = kept
+	models := []Model{}
+	for _, m := range modelList {
+		if m.Name != name {
+			models = append(models, m)
+		}
+	}
+	modelList = models
+	return c.NoContent(http.StatusNoContent)
+}
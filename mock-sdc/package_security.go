@@ -0,0 +1,360 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SecurityValidation is the result of the checks of the hashes and the
+// signature of an ETSI SOL004 package
+type SecurityValidation struct {
+	VerifiedHashes []string `json:"verifiedHashes"`
+	Signed         bool     `json:"signed"`
+	Signer         string   `json:"signer,omitempty"`
+}
+
+// contentInfo, signedData and signerInfo are the parts of a CMS signature
+// (RFC 5652) needed to verify it
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo contentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SignerIdentifier   asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+// cmsDigests are the digest algorithms supported in CMS signatures
+var cmsDigests = map[string]crypto.Hash{
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+}
+
+// manifestDigests are the hash algorithms a SOL004 manifest may use
+var manifestDigests = map[string]crypto.Hash{
+	"SHA-256": crypto.SHA256,
+	"SHA-384": crypto.SHA384,
+	"SHA-512": crypto.SHA512,
+}
+
+// signatureAlgorithm is the x509 algorithm of a signature made with the key
+// of a certificate and the given digest
+func signatureAlgorithm(certificate *x509.Certificate, digest crypto.Hash) x509.SignatureAlgorithm {
+	algorithms := map[x509.PublicKeyAlgorithm]map[crypto.Hash]x509.SignatureAlgorithm{
+		x509.RSA:   {crypto.SHA256: x509.SHA256WithRSA, crypto.SHA384: x509.SHA384WithRSA, crypto.SHA512: x509.SHA512WithRSA},
+		x509.ECDSA: {crypto.SHA256: x509.ECDSAWithSHA256, crypto.SHA384: x509.ECDSAWithSHA384, crypto.SHA512: x509.ECDSAWithSHA512},
+	}
+	return algorithms[certificate.PublicKeyAlgorithm][digest]
+}
+
+// signerCertificate returns the certificate of the signer among the
+// certificates of the signature and the given ones
+func signerCertificate(signer signerInfo, certificates []*x509.Certificate) (*x509.Certificate, error) {
+	if signer.SignerIdentifier.Class == asn1.ClassContextSpecific {
+		for _, certificate := range certificates {
+			if bytes.Equal(certificate.SubjectKeyId, signer.SignerIdentifier.Bytes) {
+				return certificate, nil
+			}
+		}
+		return nil, errors.New("signer certificate not found")
+	}
+	id := issuerAndSerialNumber{}
+	if _, err := asn1.Unmarshal(signer.SignerIdentifier.FullBytes, &id); err != nil {
+		return nil, errors.New("invalid signer identifier")
+	}
+	for _, certificate := range certificates {
+		if bytes.Equal(certificate.RawIssuer, id.Issuer.FullBytes) && certificate.SerialNumber.Cmp(id.SerialNumber) == 0 {
+			return certificate, nil
+		}
+	}
+	return nil, errors.New("signer certificate not found")
+}
+
+// signedBytes returns what the signer signed: the DER encoded signed
+// attributes, after checking their message digest, or the content itself
+func signedBytes(signer signerInfo, content []byte, digest crypto.Hash) ([]byte, error) {
+	if len(signer.SignedAttributes.FullBytes) == 0 {
+		return content, nil
+	}
+	attributes := []cmsAttribute{}
+	if _, err := asn1.UnmarshalWithParams(signer.SignedAttributes.FullBytes, &attributes, "set,tag:0"); err != nil {
+		return nil, errors.New("invalid signed attributes")
+	}
+	hash := digest.New()
+	hash.Write(content)
+	for _, attribute := range attributes {
+		if !attribute.Type.Equal(oidMessageDigest) {
+			continue
+		}
+		messageDigest := []byte{}
+		if _, err := asn1.Unmarshal(attribute.Values.Bytes, &messageDigest); err != nil {
+			return nil, errors.New("invalid message digest")
+		}
+		if !bytes.Equal(messageDigest, hash.Sum(nil)) {
+			return nil, errors.New("message digest does not match the signed content")
+		}
+		signed := append([]byte{}, signer.SignedAttributes.FullBytes...)
+		signed[0] = 0x31
+		return signed, nil
+	}
+	return nil, errors.New("signed attributes have no message digest")
+}
+
+// verifyCms verifies a detached CMS signature of content. The signer
+// certificate is searched in the signature and in the given certificates,
+// and must chain to the trust store.
+func verifyCms(signature []byte, content []byte, certificates []*x509.Certificate, roots *x509.CertPool) (*x509.Certificate, error) {
+	info := contentInfo{}
+	if _, err := asn1.Unmarshal(signature, &info); err != nil || !info.ContentType.Equal(oidSignedData) {
+		return nil, errors.New("invalid CMS signature")
+	}
+	data := signedData{}
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &data); err != nil {
+		return nil, errors.New("invalid CMS signed data")
+	}
+	if len(data.SignerInfos) == 0 {
+		return nil, errors.New("CMS signature has no signer")
+	}
+	if len(data.Certificates.Bytes) != 0 {
+		embedded, err := x509.ParseCertificates(data.Certificates.Bytes)
+		if err != nil {
+			return nil, errors.New("invalid certificate in CMS signature")
+		}
+		certificates = append(embedded, certificates...)
+	}
+	signer := data.SignerInfos[0]
+	certificate, err := signerCertificate(signer, certificates)
+	if err != nil {
+		return nil, err
+	}
+	digest, found := cmsDigests[signer.DigestAlgorithm.Algorithm.String()]
+	if !found {
+		return nil, errors.New("unsupported digest algorithm " + signer.DigestAlgorithm.Algorithm.String())
+	}
+	signed, err := signedBytes(signer, content, digest)
+	if err != nil {
+		return nil, err
+	}
+	if err := certificate.CheckSignature(signatureAlgorithm(certificate, digest), signed, signer.Signature); err != nil {
+		return nil, errors.New("invalid signature: " + err.Error())
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certificates {
+		intermediates.AddCert(c)
+	}
+	if _, err := certificate.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, errors.New("certificate " + certificate.Subject.String() + " is not trusted: " + err.Error())
+	}
+	return certificate, nil
+}
+
+// parseCertificates reads the PEM or DER certificates of a file
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	certificates := []*x509.Certificate{}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return x509.ParseCertificates(data)
+	}
+	return certificates, nil
+}
+
+// trustStore returns the certificates packages are verified against: the
+// PEM file TRUST_STORE points to, or the certificates of the directory
+func trustStore() (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	location := os.Getenv("TRUST_STORE")
+	if location == "" {
+		return pool, nil
+	}
+	files := []string{location}
+	if info, err := os.Stat(location); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(location, "*")); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range files {
+		if info, err := os.Stat(file); err != nil || info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		certificates, err := parseCertificates(data)
+		if err != nil {
+			return nil, errors.New("invalid certificate in trust store " + file)
+		}
+		for _, certificate := range certificates {
+			pool.AddCert(certificate)
+		}
+	}
+	return pool, nil
+}
+
+// securityError records a failed check of a file of the package
+func (p *OnboardedPackage) securityError(file string, message string) {
+	if p.SecurityErrors == nil {
+		p.SecurityErrors = map[string][]ErrorMessage{}
+	}
+	p.SecurityErrors[file] = append(p.SecurityErrors[file], ErrorMessage{Level: "ERROR", Message: message})
+}
+
+// verifySignature checks the CMS signature of content against the trust
+// store, with the certificate file of the package if any
+func (p *OnboardedPackage) verifySignature(name string, signature []byte, content []byte, certificateFile []byte) {
+	p.Security.Signed = true
+	certificates := []*x509.Certificate{}
+	if certificateFile != nil {
+		var err error
+		if certificates, err = parseCertificates(certificateFile); err != nil {
+			p.securityError(name, "invalid certificate: "+err.Error())
+			return
+		}
+	}
+	roots, err := trustStore()
+	if err != nil {
+		p.securityError(name, "cannot read the trust store: "+err.Error())
+		return
+	}
+	certificate, err := verifyCms(signature, content, certificates, roots)
+	if err != nil {
+		p.securityError(name, err.Error())
+		return
+	}
+	p.Security.Signer = certificate.Subject.String()
+}
+
+// verifyManifest checks the hashes of the files given in a SOL004 manifest
+// and its signature, whose certificate may be the ETSI-Entry-Certificate of
+// TOSCA.meta
+func (p *OnboardedPackage) verifyManifest(manifestName string, manifest EtsiManifest) {
+	p.Security.VerifiedHashes = []string{}
+	for _, source := range sortedKeys(manifest.Digests) {
+		digest := manifest.Digests[source]
+		algorithm, found := manifestDigests[strings.ToUpper(digest.Algorithm)]
+		if !found {
+			p.securityError(source, "unsupported hash algorithm "+digest.Algorithm+" in "+manifestName)
+			continue
+		}
+		data, found := p.Files[source]
+		if !found {
+			continue
+		}
+		hash := algorithm.New()
+		hash.Write(data)
+		if !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), digest.Hash) {
+			p.securityError(source, "hash of "+source+" does not match "+manifestName)
+			continue
+		}
+		p.Security.VerifiedHashes = append(p.Security.VerifiedHashes, source)
+	}
+	if manifest.Signature == nil {
+		return
+	}
+	var certificate []byte
+	if meta, found := p.Files["TOSCA-Metadata/TOSCA.meta"]; found {
+		if name := metadataValue(parseToscaMeta(meta), "ETSI-Entry-Certificate"); name != "" {
+			if certificate, found = p.Files[name]; !found {
+				p.securityError(manifestName, "certificate "+name+" not found in the package")
+				return
+			}
+		}
+	}
+	p.verifySignature(manifestName, manifest.Signature, manifest.SignedContent, certificate)
+}
+
+// signedCsar returns the CSAR, its signature and certificate of a package
+// signed as a whole, a zip holding only them
+func signedCsar(files map[string][]byte) (string, []byte, []byte, bool) {
+	csarName, signature, certificate := "", []byte(nil), []byte(nil)
+	for name, data := range files {
+		switch path.Ext(name) {
+		case ".csar":
+			csarName = name
+		case ".cms":
+			signature = data
+		case ".cert", ".crt", ".pem":
+			certificate = data
+		default:
+			return "", nil, nil, false
+		}
+	}
+	if csarName == "" || signature == nil {
+		return "", nil, nil, false
+	}
+	if block, _ := pem.Decode(signature); block != nil {
+		signature = block.Bytes
+	}
+	return csarName, signature, certificate, true
+}
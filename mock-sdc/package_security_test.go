@@ -0,0 +1,313 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	oidData   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSA    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+)
+
+// testSigner is a certificate with its key
+type testSigner struct {
+	certificate *x509.Certificate
+	key         *rsa.PrivateKey
+}
+
+// newTestSigner creates a certificate issued by parent, or a self-signed CA
+// when parent is nil
+func newTestSigner(t *testing.T, name string, parent *testSigner) *testSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		SubjectKeyId:          []byte(name),
+		BasicConstraintsValid: true,
+	}
+	issuer, issuerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		issuer, issuerKey = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigner{certificate: certificate, key: key}
+}
+
+// cmsOptions tell how testSignature builds a signature
+type cmsOptions struct {
+	noAttributes  bool
+	keyIdentifier bool
+	messageDigest []byte
+	noCertificate bool
+}
+
+func mustMarshal(t *testing.T, value interface{}) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// testSignature returns a detached CMS signature of content by signer
+func testSignature(t *testing.T, signer *testSigner, content []byte, options cmsOptions) []byte {
+	t.Helper()
+	type attribute struct {
+		Type   asn1.ObjectIdentifier
+		Values asn1.RawValue
+	}
+	type signerInfo struct {
+		Version            int
+		SignerIdentifier   asn1.RawValue
+		DigestAlgorithm    pkix.AlgorithmIdentifier
+		SignedAttributes   asn1.RawValue `asn1:"optional"`
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          []byte
+	}
+	type encapsulatedContentInfo struct {
+		ContentType asn1.ObjectIdentifier
+	}
+	type signedData struct {
+		Version          int
+		DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+		EncapContentInfo encapsulatedContentInfo
+		Certificates     asn1.RawValue `asn1:"optional"`
+		SignerInfos      []signerInfo  `asn1:"set"`
+	}
+	set := func(content []byte) asn1.RawValue {
+		return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: content}
+	}
+	digest := sha256.Sum256(content)
+	info := signerInfo{
+		Version:            1,
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSA, Parameters: asn1.NullRawValue},
+	}
+	if options.keyIdentifier {
+		info.Version = 3
+		info.SignerIdentifier = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: signer.certificate.SubjectKeyId}
+	} else {
+		info.SignerIdentifier = asn1.RawValue{FullBytes: mustMarshal(t, issuerAndSerialNumber{
+			Issuer:       asn1.RawValue{FullBytes: signer.certificate.RawIssuer},
+			SerialNumber: signer.certificate.SerialNumber,
+		})}
+	}
+	signed := content
+	if !options.noAttributes {
+		messageDigest := digest[:]
+		if options.messageDigest != nil {
+			messageDigest = options.messageDigest
+		}
+		attributes := mustMarshal(t, attribute{Type: asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}, Values: set(mustMarshal(t, oidData))})
+		attributes = append(attributes, mustMarshal(t, attribute{Type: oidMessageDigest, Values: set(mustMarshal(t, messageDigest))})...)
+		signed = mustMarshal(t, set(attributes))
+		info.SignedAttributes = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attributes}
+	}
+	hash := sha256.Sum256(signed)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, signer.key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.Signature = signature
+	data := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{info.DigestAlgorithm},
+		EncapContentInfo: encapsulatedContentInfo{ContentType: oidData},
+		SignerInfos:      []signerInfo{info},
+	}
+	if !options.noCertificate {
+		data.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signer.certificate.Raw}
+	}
+	return mustMarshal(t, struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: mustMarshal(t, data)},
+	})
+}
+
+func TestVerifyCms(t *testing.T) {
+	ca := newTestSigner(t, "Test CA", nil)
+	leaf := newTestSigner(t, "Vendor Signer", ca)
+	other := newTestSigner(t, "Other Signer", newTestSigner(t, "Other CA", nil))
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	content := []byte("metadata:\n  pnfd_name: gnb\n")
+	tests := []struct {
+		name         string
+		signer       *testSigner
+		options      cmsOptions
+		content      []byte
+		certificates []*x509.Certificate
+		err          string
+	}{
+		{name: "signed attributes", signer: leaf},
+		{name: "no signed attributes", signer: leaf, options: cmsOptions{noAttributes: true}},
+		{name: "subject key identifier", signer: leaf, options: cmsOptions{keyIdentifier: true}},
+		{name: "certificate given apart", signer: leaf, options: cmsOptions{noCertificate: true}, certificates: []*x509.Certificate{leaf.certificate}},
+		{name: "certificate missing", signer: leaf, options: cmsOptions{noCertificate: true}, err: "signer certificate not found"},
+		{name: "tampered content", signer: leaf, content: []byte("metadata:\n  pnfd_name: other\n"), err: "message digest does not match"},
+		{name: "tampered content without attributes", signer: leaf, options: cmsOptions{noAttributes: true}, content: []byte("tampered"), err: "invalid signature"},
+		{name: "wrong message digest", signer: leaf, options: cmsOptions{messageDigest: make([]byte, 32)}, err: "message digest does not match"},
+		{name: "untrusted signer", signer: other, err: "is not trusted"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signature := testSignature(t, test.signer, content, test.options)
+			verified := content
+			if test.content != nil {
+				verified = test.content
+			}
+			certificate, err := verifyCms(signature, verified, test.certificates, roots)
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !certificate.Equal(test.signer.certificate) {
+					t.Fatalf("signer is %s, not %s", certificate.Subject, test.signer.certificate.Subject)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("error is %v, expected %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestVerifyCmsInvalid(t *testing.T) {
+	if _, err := verifyCms([]byte("not a signature"), nil, nil, x509.NewCertPool()); err == nil {
+		t.Fatal("invalid signature accepted")
+	}
+}
+
+// testPackage returns a package whose manifest gives the SHA-256 of its
+// files, and the wrong hash of the ones in wrongHashes
+func testPackage(files map[string][]byte, wrongHashes ...string) (*OnboardedPackage, []byte) {
+	manifest := "metadata:\n  pnfd_name: gnb\n  pnfd_provider: acme\n  pnfd_archive_version: \"1.0\"\n  pnfd_release_date_time: 2023-01-01T00:00:00+00:00\n"
+	for _, name := range sortedKeys(files) {
+		hash := sha256.Sum256(files[name])
+		if containsString(wrongHashes, name) {
+			hash = sha256.Sum256([]byte("other content"))
+		}
+		manifest += "\nSource: " + name + "\nAlgorithm: SHA-256\nHash: " + hex.EncodeToString(hash[:]) + "\n"
+	}
+	return &OnboardedPackage{Files: files, Security: &SecurityValidation{}}, []byte(manifest)
+}
+
+func TestVerifyManifestHashes(t *testing.T) {
+	p, data := testPackage(map[string][]byte{
+		"Definitions/pnfd.yaml": []byte("tosca_definitions_version: tosca_simple_yaml_1_2\n"),
+		"Artifacts/sw.yaml":     []byte("pnf_software_information: []\n"),
+	}, "Definitions/pnfd.yaml")
+	manifest, err := parseEtsiManifest(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.verifyManifest("gnb.mf", manifest)
+	if len(p.Security.VerifiedHashes) != 1 || p.Security.VerifiedHashes[0] != "Artifacts/sw.yaml" {
+		t.Fatalf("verified hashes are %v", p.Security.VerifiedHashes)
+	}
+	if len(p.SecurityErrors) != 1 || len(p.SecurityErrors["Definitions/pnfd.yaml"]) != 1 {
+		t.Fatalf("errors are %v", p.SecurityErrors)
+	}
+	if p.Security.Signed {
+		t.Fatal("unsigned manifest reported as signed")
+	}
+}
+
+func TestVerifyManifestSignature(t *testing.T) {
+	ca := newTestSigner(t, "Test CA", nil)
+	leaf := newTestSigner(t, "Vendor Signer", ca)
+	other := newTestSigner(t, "Other Signer", newTestSigner(t, "Other CA", nil))
+	trustStore := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(trustStore, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.certificate.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TRUST_STORE", trustStore)
+	tests := []struct {
+		name     string
+		signer   *testSigner
+		tampered bool
+		err      string
+	}{
+		{name: "trusted", signer: leaf},
+		{name: "untrusted", signer: other, err: "is not trusted"},
+		{name: "tampered", signer: leaf, tampered: true, err: "message digest does not match"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, data := testPackage(map[string][]byte{"Definitions/pnfd.yaml": []byte("tosca_definitions_version: tosca_simple_yaml_1_2\n")})
+			signature := pem.EncodeToMemory(&pem.Block{Type: "CMS", Bytes: testSignature(t, test.signer, data, cmsOptions{})})
+			if test.tampered {
+				data = append(data, []byte("\nSource: Artifacts/extra.yaml\n")...)
+			}
+			manifest, err := parseEtsiManifest(append(data, signature...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.verifyManifest("gnb.mf", manifest)
+			if !p.Security.Signed {
+				t.Fatal("signed manifest reported as unsigned")
+			}
+			if test.err == "" {
+				if len(p.SecurityErrors) != 0 || p.Security.Signer != leaf.certificate.Subject.String() {
+					t.Fatalf("errors are %v, signer %q", p.SecurityErrors, p.Security.Signer)
+				}
+				return
+			}
+			errors := p.SecurityErrors["gnb.mf"]
+			if len(errors) != 1 || !strings.Contains(errors[0].Message, test.err) {
+				t.Fatalf("errors are %v, expected %q", p.SecurityErrors, test.err)
+			}
+		})
+	}
+}
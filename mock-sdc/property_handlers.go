@@ -0,0 +1,430 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// SchemaProperty is the type of the entries of a list or a map
+type SchemaProperty struct {
+	Type string `json:"type"`
+}
+
+// PropertySchema describes the entry_schema of list and map properties
+type PropertySchema struct {
+	Property SchemaProperty `json:"property"`
+}
+
+// Property format
+type Property struct {
+	Name           string          `json:"name"`
+	Value          string          `json:"value"`
+	Type           string          `json:"type"`
+	UniqueID       string          `json:"uniqueId"`
+	ParentUniqueID string          `json:"parentUniqueId"`
+	Description    string          `json:"description"`
+	Required       bool            `json:"required"`
+	Schema         *PropertySchema `json:"schema,omitempty"`
+}
+
+// PropertyBody is a property as sent by clients, the value may be either a
+// string or any JSON value
+type PropertyBody struct {
+	Name        string          `json:"name"`
+	Value       json.RawMessage `json:"value"`
+	Type        string          `json:"type"`
+	Description string          `json:"description"`
+	Required    bool            `json:"required"`
+	Schema      *PropertySchema `json:"schema"`
+}
+
+func (p PropertyBody) toProperty() Property {
+	value := ""
+	if len(p.Value) != 0 && string(p.Value) != "null" {
+		if err := json.Unmarshal(p.Value, &value); err != nil {
+			value = string(p.Value)
+		}
+	}
+	return Property{
+		Name:        p.Name,
+		Value:       value,
+		Type:        p.Type,
+		Description: p.Description,
+		Required:    p.Required,
+		Schema:      p.Schema,
+	}
+}
+
+// parsePropertiesBody reads properties given either as a map (name to
+// property, as SDC UI does), as a list or as a single property
+func parsePropertiesBody(body io.Reader) ([]Property, error) {
+	bodyBytes, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	properties := []Property{}
+	var list []PropertyBody
+	if err := json.Unmarshal(bodyBytes, &list); err == nil {
+		for _, p := range list {
+			properties = append(properties, p.toProperty())
+		}
+		return properties, nil
+	}
+	var dat map[string]json.RawMessage
+	if err := json.Unmarshal(bodyBytes, &dat); err != nil {
+		return nil, err
+	}
+	single := PropertyBody{}
+	var name string
+	if json.Unmarshal(dat["name"], &name) == nil {
+		if err := json.Unmarshal(bodyBytes, &single); err != nil {
+			return nil, err
+		}
+		return append(properties, single.toProperty()), nil
+	}
+	keys := []string{}
+	for key := range dat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p := PropertyBody{}
+		if err := json.Unmarshal(dat[key], &p); err != nil {
+			if err := json.Unmarshal(bodyBytes, &single); err != nil {
+				return nil, err
+			}
+			return []Property{single.toProperty()}, nil
+		}
+		if p.Name == "" {
+			p.Name = key
+		}
+		properties = append(properties, p.toProperty())
+	}
+	return properties, nil
+}
+
+var toscaScalarTypes = []string{
+	"string", "integer", "float", "boolean", "timestamp", "version", "range",
+	"json", "scalar-unit.size", "scalar-unit.time", "scalar-unit.frequency",
+}
+
+// isKnownPropertyType tells if a type is a TOSCA scalar type or a data type
+// of the model of the component
+func isKnownPropertyType(typeName string, types *ToscaTypeDefinitions) bool {
+	_, found := types.DataTypes[typeName]
+	return containsString(toscaScalarTypes, typeName) || found
+}
+
+func schemaType(p Property) string {
+	if p.Schema == nil {
+		return ""
+	}
+	return p.Schema.Property.Type
+}
+
+// isGetFunction tells if a value is a TOSCA function such as get_input,
+// which is valid whatever the property type
+func isGetFunction(value string) bool {
+	var function map[string]interface{}
+	if json.Unmarshal([]byte(value), &function) != nil || len(function) != 1 {
+		return false
+	}
+	for name := range function {
+		return name == "get_input" || name == "get_property" || name == "get_attribute"
+	}
+	return false
+}
+
+// validateValue checks a value against a TOSCA type. The value is either
+// the string stored in a property or an entry decoded from a list, a map or
+// a data type
+func validateValue(typeName string, entryType string, value interface{}, types *ToscaTypeDefinitions) error {
+	invalid := errors.New("value is not a valid " + typeName)
+	text, isString := value.(string)
+	switch typeName {
+	case "string", "timestamp", "version", "scalar-unit.size", "scalar-unit.time", "scalar-unit.frequency":
+		if !isString {
+			return invalid
+		}
+	case "integer":
+		if isString {
+			if _, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err != nil {
+				return invalid
+			}
+		} else if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			return invalid
+		}
+	case "float":
+		if isString {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err != nil {
+				return invalid
+			}
+		} else if _, ok := value.(float64); !ok {
+			return invalid
+		}
+	case "boolean":
+		if isString {
+			if !strings.EqualFold(text, "true") && !strings.EqualFold(text, "false") {
+				return invalid
+			}
+		} else if _, ok := value.(bool); !ok {
+			return invalid
+		}
+	case "json", "range":
+		if isString && !json.Valid([]byte(text)) {
+			return invalid
+		}
+	case "list":
+		entries, ok := value.([]interface{})
+		if isString && json.Unmarshal([]byte(text), &entries) != nil {
+			return invalid
+		} else if !isString && !ok {
+			return invalid
+		}
+		for _, entry := range entries {
+			if err := validateValue(entryType, "", entry, types); err != nil {
+				return errors.New("list entry " + err.Error())
+			}
+		}
+	case "map":
+		entries, ok := value.(map[string]interface{})
+		if isString && json.Unmarshal([]byte(text), &entries) != nil {
+			return invalid
+		} else if !isString && !ok {
+			return invalid
+		}
+		for key, entry := range entries {
+			if err := validateValue(entryType, "", entry, types); err != nil {
+				return errors.New("map entry " + key + " " + err.Error())
+			}
+		}
+	default:
+		if _, found := types.DataTypes[typeName]; !found {
+			return errors.New("unknown type " + typeName)
+		}
+		if base := scalarBase(types, typeName); base != "" {
+			return validateValue(base, "", value, types)
+		}
+		fields, ok := value.(map[string]interface{})
+		if isString && json.Unmarshal([]byte(text), &fields) != nil {
+			return invalid
+		} else if !isString && !ok {
+			return invalid
+		}
+		for _, field := range sortedKeys(fields) {
+			definition, found := dataTypeProperty(types, typeName, field)
+			if !found {
+				return errors.New("unknown field " + field + " of " + typeName)
+			}
+			entryType := ""
+			if definition.EntrySchema != nil {
+				entryType = definition.EntrySchema.Type
+			}
+			if err := validateValue(definition.Type, entryType, fields[field], types); err != nil {
+				return errors.New("field " + field + " " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// validateProperty checks the type, the entry_schema and the value of a
+// property against the types of the model of its component
+func validateProperty(p Property, types *ToscaTypeDefinitions) *SdcError {
+	if p.Name == "" {
+		_, sdcError := newSdcError("MISSING_PROPERTY_NAME")
+		return sdcError
+	}
+	if !isKnownPropertyType(p.Type, types) && p.Type != "list" && p.Type != "map" {
+		_, sdcError := newSdcError("INVALID_PROPERTY_TYPE", p.Type)
+		return sdcError
+	}
+	if (p.Type == "list" || p.Type == "map") && !isKnownPropertyType(schemaType(p), types) {
+		_, sdcError := newSdcError("INVALID_PROPERTY_TYPE", schemaType(p))
+		return sdcError
+	}
+	if p.Value == "" || isGetFunction(p.Value) {
+		return nil
+	}
+	if err := validateValue(p.Type, schemaType(p), p.Value, types); err != nil {
+		_, sdcError := newSdcError("INVALID_PROPERTY_VALUE", p.Name, p.Type, err.Error())
+		return sdcError
+	}
+	return nil
+}
+
+func findProperty(properties []Property, propertyID string) int {
+	for i, p := range properties {
+		if p.UniqueID == propertyID || p.Name == propertyID {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkoutResourceIndex returns the index of a resource that the caller may
+// modify, along with the caller, or writes the error response. Handlers set
+// the caller as last updater once their change is made.
+func checkoutResourceIndex(c echo.Context) (int, User, error) {
+	user, ok, err := authorizeUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return -1, user, err
+	}
+	i := findResourceIndex(c.Param("resourceID"))
+	if i < 0 {
+		return i, user, c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	if resourceList[i].LifecycleState != "NOT_CERTIFIED_CHECKOUT" {
+		return -1, user, c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Cannot perform this action",
+			ErrorCode: "SVC3642",
+			Status:    "Bad Action"})
+	}
+	if resourceList[i].Archived {
+		return -1, user, c.JSON(http.StatusBadRequest, archivedComponentError(resourceList[i]))
+	}
+	if sdcError := checkLock(resourceList[i], user); sdcError != nil {
+		return -1, user, c.JSON(http.StatusForbidden, sdcError)
+	}
+	return i, user, nil
+}
+
+func postResourceProperties(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	properties, err := parsePropertiesBody(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	created := map[string]Property{}
+	for _, p := range properties {
+		if sdcError := validateProperty(p, modelTypes(resourceList[i].Model)); sdcError != nil {
+			return c.JSON(http.StatusBadRequest, sdcError)
+		}
+		if _, exists := created[p.Name]; exists || findProperty(resourceList[i].Properties, p.Name) >= 0 {
+			return c.JSON(http.StatusConflict, SdcError{
+				Message:   "Property with " + p.Name + " name already exists.",
+				ErrorCode: "SVC4101",
+				Status:    "Exists"})
+		}
+		p.UniqueID = resourceList[i].UniqueID + "." + p.Name
+		p.ParentUniqueID = resourceList[i].UniqueID
+		created[p.Name] = p
+	}
+	for _, p := range properties {
+		resourceList[i].Properties = append(resourceList[i].Properties, created[p.Name])
+	}
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, created)
+}
+
+func getResourceProperty(c echo.Context) error {
+	i := findResourceIndex(c.Param("resourceID"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	j := findProperty(resourceList[i].Properties, c.Param("propertyID"))
+	if j < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Property " + c.Param("propertyID") + " not found.",
+			ErrorCode: "SVC4315",
+			Status:    "Not Found"})
+	}
+	p := resourceList[i].Properties[j]
+	return c.JSON(http.StatusOK, map[string]Property{p.Name: p})
+}
+
+func putResourceProperty(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	j := findProperty(resourceList[i].Properties, c.Param("propertyID"))
+	if j < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Property " + c.Param("propertyID") + " not found.",
+			ErrorCode: "SVC4315",
+			Status:    "Not Found"})
+	}
+	properties, err := parsePropertiesBody(c.Request().Body)
+	if err != nil || len(properties) != 1 {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	updated := properties[0]
+	current := resourceList[i].Properties[j]
+	if updated.Name == "" {
+		updated.Name = current.Name
+	}
+	if updated.Type == "" {
+		updated.Type = current.Type
+		updated.Schema = current.Schema
+	}
+	if sdcError := validateProperty(updated, modelTypes(resourceList[i].Model)); sdcError != nil {
+		return c.JSON(http.StatusBadRequest, sdcError)
+	}
+	if updated.Name != current.Name && findProperty(resourceList[i].Properties, updated.Name) >= 0 {
+		return c.JSON(http.StatusConflict, SdcError{
+			Message:   "Property with " + updated.Name + " name already exists.",
+			ErrorCode: "SVC4101",
+			Status:    "Exists"})
+	}
+	updated.UniqueID = resourceList[i].UniqueID + "." + updated.Name
+	updated.ParentUniqueID = resourceList[i].UniqueID
+	resourceList[i].Properties[j] = updated
+	setLastUpdater(&resourceList[i], user)
+	return c.JSON(http.StatusOK, map[string]Property{updated.Name: updated})
+}
+
+func deleteResourceProperty(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	j := findProperty(resourceList[i].Properties, c.Param("propertyID"))
+	if j < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Property " + c.Param("propertyID") + " not found.",
+			ErrorCode: "SVC4315",
+			Status:    "Not Found"})
+	}
+	properties := resourceList[i].Properties
+	resourceList[i].Properties = append(properties[:j:j], properties[j+1:]...)
+	setLastUpdater(&resourceList[i], user)
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,265 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+// RelationshipImpl is the TOSCA relationship of a relation
+type RelationshipImpl struct {
+	Type string `json:"type"`
+}
+
+// RelationshipInfo tells which requirement of an instance is fulfilled by
+// which capability of another one
+type RelationshipInfo struct {
+	ID                 string           `json:"id"`
+	Requirement        string           `json:"requirement"`
+	RequirementOwnerID string           `json:"requirementOwnerId"`
+	Capability         string           `json:"capability"`
+	CapabilityOwnerID  string           `json:"capabilityOwnerId"`
+	Relationship       RelationshipImpl `json:"relationship"`
+}
+
+// CapabilityRequirementRelationship is a relationship of a relation
+type CapabilityRequirementRelationship struct {
+	Relation RelationshipInfo `json:"relation"`
+}
+
+// RequirementCapabilityRelation holds the relationships between two
+// component instances
+type RequirementCapabilityRelation struct {
+	FromNode      string                              `json:"fromNode"`
+	ToNode        string                              `json:"toNode"`
+	Relationships []CapabilityRequirementRelationship `json:"relationships"`
+}
+
+func findRelation(relations []RequirementCapabilityRelation, fromNode string, toNode string) int {
+	for i, relation := range relations {
+		if relation.FromNode == fromNode && relation.ToNode == toNode {
+			return i
+		}
+	}
+	return -1
+}
+
+// instanceNodeType returns the node type of the origin of a component
+// instance, when the model defines it
+func instanceNodeType(ci ComponentInstance, types *ToscaTypeDefinitions) (ToscaNodeType, bool) {
+	origin, found := instanceOrigin(ci)
+	if !found || origin.ToscaResourceName == "" {
+		return ToscaNodeType{}, false
+	}
+	nodeType, found := types.NodeTypes[origin.ToscaResourceName]
+	return nodeType, found
+}
+
+// nodeRequirement returns a requirement of a node type, which may be
+// inherited from the types it derives from
+func nodeRequirement(types *ToscaTypeDefinitions, nodeType ToscaNodeType, name string) (ToscaRequirementDefinition, bool) {
+	for depth := 0; depth <= len(types.NodeTypes); depth++ {
+		for _, requirements := range nodeType.Requirements {
+			if requirement, found := requirements[name]; found {
+				return requirement, true
+			}
+		}
+		parent, found := types.NodeTypes[nodeType.DerivedFrom]
+		if !found {
+			break
+		}
+		nodeType = parent
+	}
+	return ToscaRequirementDefinition{}, false
+}
+
+// nodeCapability returns a capability of a node type, which may be
+// inherited from the types it derives from
+func nodeCapability(types *ToscaTypeDefinitions, nodeType ToscaNodeType, name string) (ToscaCapabilityDefinition, bool) {
+	for depth := 0; depth <= len(types.NodeTypes); depth++ {
+		if capability, found := nodeType.Capabilities[name]; found {
+			return capability, true
+		}
+		parent, found := types.NodeTypes[nodeType.DerivedFrom]
+		if !found {
+			break
+		}
+		nodeType = parent
+	}
+	return ToscaCapabilityDefinition{}, false
+}
+
+// validTargetTypes returns the capability types a relationship type may
+// target, which may be inherited from the types it derives from
+func validTargetTypes(types *ToscaTypeDefinitions, relationshipType string) []string {
+	for depth := 0; relationshipType != "" && depth <= len(types.RelationshipTypes); depth++ {
+		t := types.RelationshipTypes[relationshipType]
+		if len(t.ValidTargetTypes) != 0 {
+			return t.ValidTargetTypes
+		}
+		relationshipType = t.DerivedFrom
+	}
+	return nil
+}
+
+func invalidRelation(message string) *SdcError {
+	return &SdcError{
+		Message:   message,
+		ErrorCode: "SVC4000",
+		Status:    "Invalid Content"}
+}
+
+// validateRelation checks a relationship between the instances from and to
+// against the types of the model. The requirement and the capability are
+// only checked when the origins of the instances are node types of the
+// model, the other components do not describe them.
+func validateRelation(relation RelationshipInfo, from ComponentInstance, to ComponentInstance, types *ToscaTypeDefinitions) *SdcError {
+	if relation.Requirement == "" || relation.Capability == "" {
+		return invalidRelation("Missing requirement or capability of the relation.")
+	}
+	relationshipType := relation.Relationship.Type
+	if _, found := types.RelationshipTypes[relationshipType]; !found {
+		return invalidRelation("Invalid relationship type " + relationshipType + ".")
+	}
+	requirement := ToscaRequirementDefinition{}
+	if nodeType, found := instanceNodeType(from, types); found {
+		if requirement, found = nodeRequirement(types, nodeType, relation.Requirement); !found {
+			return invalidRelation("Requirement " + relation.Requirement + " not found on " + from.Name + ".")
+		}
+		if requirement.Relationship != "" && !typeDerivesFrom(types.RelationshipTypes, relationshipType, requirement.Relationship) {
+			return invalidRelation("Requirement " + relation.Requirement + " of " + from.Name + " needs a " + requirement.Relationship + " relationship.")
+		}
+	}
+	nodeType, found := instanceNodeType(to, types)
+	if !found {
+		return nil
+	}
+	capability, found := nodeCapability(types, nodeType, relation.Capability)
+	if !found {
+		return invalidRelation("Capability " + relation.Capability + " not found on " + to.Name + ".")
+	}
+	if requirement.Capability != "" && !typeDerivesFrom(types.CapabilityTypes, capability.Type, requirement.Capability) {
+		return invalidRelation("Capability " + relation.Capability + " of " + to.Name + " does not fulfill requirement " + relation.Requirement + ".")
+	}
+	targets := validTargetTypes(types, relationshipType)
+	for _, target := range targets {
+		if typeDerivesFrom(types.CapabilityTypes, capability.Type, target) {
+			return nil
+		}
+	}
+	if len(targets) != 0 {
+		return invalidRelation("Relationship " + relationshipType + " cannot target capability " + relation.Capability + " of " + to.Name + ".")
+	}
+	return nil
+}
+
+// relationInstances returns the indexes of the instances of a relation in
+// the component i, or writes the error response
+func relationInstances(c echo.Context, i int, relation RequirementCapabilityRelation) (int, int, error) {
+	instances := resourceList[i].ComponentInstances
+	for _, id := range []string{relation.FromNode, relation.ToNode} {
+		if findComponentInstance(instances, id) < 0 {
+			return -1, -1, c.JSON(newSdcError("COMPONENT_INSTANCE_NOT_FOUND", id))
+		}
+	}
+	return findComponentInstance(instances, relation.FromNode), findComponentInstance(instances, relation.ToNode), nil
+}
+
+// postAssociate adds relationships between two component instances
+func postAssociate(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	relation := new(RequirementCapabilityRelation)
+	if err := c.Bind(relation); err != nil || len(relation.Relationships) == 0 {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	from, to, err := relationInstances(c, i, *relation)
+	if from < 0 {
+		return err
+	}
+	r := &resourceList[i]
+	types := modelTypes(r.Model)
+	for k := range relation.Relationships {
+		info := &relation.Relationships[k].Relation
+		if sdcError := validateRelation(*info, r.ComponentInstances[from], r.ComponentInstances[to], types); sdcError != nil {
+			return c.JSON(http.StatusBadRequest, sdcError)
+		}
+		info.ID = uuid.NewV4().String()
+		info.RequirementOwnerID = relation.FromNode
+		info.CapabilityOwnerID = relation.ToNode
+	}
+	j := findRelation(r.ComponentInstancesRelations, relation.FromNode, relation.ToNode)
+	if j < 0 {
+		r.ComponentInstancesRelations = append(r.ComponentInstancesRelations, RequirementCapabilityRelation{
+			FromNode: relation.FromNode,
+			ToNode:   relation.ToNode,
+		})
+		j = len(r.ComponentInstancesRelations) - 1
+	}
+	r.ComponentInstancesRelations[j].Relationships = append(r.ComponentInstancesRelations[j].Relationships, relation.Relationships...)
+	setLastUpdater(r, user)
+	return c.JSON(http.StatusOK, relation)
+}
+
+// putDissociate removes relationships between two component instances,
+// given by id or by requirement and capability
+func putDissociate(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	relation := new(RequirementCapabilityRelation)
+	if err := c.Bind(relation); err != nil || len(relation.Relationships) == 0 {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	r := &resourceList[i]
+	j := findRelation(r.ComponentInstancesRelations, relation.FromNode, relation.ToNode)
+	if j < 0 {
+		return c.JSON(newSdcError("RELATION_NOT_FOUND", relation.FromNode, relation.ToNode))
+	}
+	kept := []CapabilityRequirementRelationship{}
+	for _, existing := range r.ComponentInstancesRelations[j].Relationships {
+		removed := false
+		for _, relationship := range relation.Relationships {
+			info := relationship.Relation
+			removed = removed || info.ID == existing.Relation.ID ||
+				(info.ID == "" && info.Requirement == existing.Relation.Requirement && info.Capability == existing.Relation.Capability)
+		}
+		if !removed {
+			kept = append(kept, existing)
+		}
+	}
+	if len(kept) == len(r.ComponentInstancesRelations[j].Relationships) {
+		return c.JSON(newSdcError("RELATION_NOT_FOUND", relation.FromNode, relation.ToNode))
+	}
+	if len(kept) == 0 {
+		r.ComponentInstancesRelations = append(r.ComponentInstancesRelations[:j], r.ComponentInstancesRelations[j+1:]...)
+	} else {
+		r.ComponentInstancesRelations[j].Relationships = kept
+	}
+	setLastUpdater(r, user)
+	return c.JSON(http.StatusOK, relation)
+}
@@ -15,11 +15,11 @@
 package main
 
 import (
-	"container/list"
-	"encoding/json"
-	"errors"
-	"io/ioutil"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo"
 	uuid "github.com/satori/go.uuid"
@@ -38,88 +38,76 @@ type ResourceLight struct {
 	Version            string `json:"version"`
 	ToscaModelURL      string `json:"toscaModelURL"`
 	DistributionStatus string `json:"distributionStatus"`
-}
-
-// SubCategory describes SubCategory model in SDC
-type SubCategory struct {
-	Name           string   `json:"name"`
-	NormalizedName string   `json:"normalizedName"`
-	UniqueID       string   `json:"uniqueId"`
-	Icons          []string `json:"icons"`
-	Groupings      string   `json:"groupings"`
-	OwnerID        string   `json:"ownerId"`
-	Empty          bool     `json:"empty"`
-	Version        string   `json:"version"`
-	Type           string   `json:"type"`
-}
-
-// Category describes Category model in SDC
-type Category struct {
-	Name           string        `json:"name"`
-	NormalizedName string        `json:"normalizedName"`
-	UniqueID       string        `json:"uniqueId"`
-	Icons          []string      `json:"icons"`
-	Subcategories  []SubCategory `json:"subcategories"`
-	OwnerID        string        `json:"ownerId"`
-	Empty          bool          `json:"empty"`
-	Type           string        `json:"type"`
-	Version        string        `json:"version"`
-}
-
-// ArtifactAdd Describes ressource component Instances artifacts in SDC
-type ArtifactAdd struct {
-	ArtifactName  string `json:"artifactName"`
-	ArtifactLabel string `json:"artifactLabel"`
-	ArtifactType  string `json:"artifactType"`
-	Description   string `json:"description"`
+	Model              string `json:"model,omitempty"`
 }
 
 // ComponentInstance Describes ressource component Instances in SDC
 type ComponentInstance struct {
-	UniqueID            string        `json:"uniqueId"`
-	Name                string        `json:"name"`
-	ComponentName       string        `json:"componentName"`
-	OriginType          string        `json:"originType"`
-	ComponentVersion    string        `json:"componentVersion"`
-	DeploymentArtifacts []ArtifactAdd `json:"deploymentArtifacts"`
+	UniqueID            string              `json:"uniqueId"`
+	Name                string              `json:"name"`
+	InvariantName       string              `json:"invariantName"`
+	NormalizedName      string              `json:"normalizedName"`
+	CustomizationUUID   string              `json:"customizationUUID"`
+	ComponentUID        string              `json:"componentUid"`
+	ComponentName       string              `json:"componentName"`
+	OriginType          string              `json:"originType"`
+	ComponentVersion    string              `json:"componentVersion"`
+	DeploymentArtifacts map[string]Artifact `json:"deploymentArtifacts"`
+	GroupInstances      []GroupInstance     `json:"groupInstances"`
+	Properties          []Property          `json:"properties"`
+	Inputs              []Input             `json:"inputs"`
 }
 
 // Resource describes Resource model in SDC
 type Resource struct {
-	ID                           string              `json:"uuid"`
-	InvariantID                  string              `json:"invariantUUID"`
-	UniqueID                     string              `json:"uniqueId"`
-	ResourceType                 string              `json:"resourceType"`
-	Name                         string              `json:"name"`
-	Category                     string              `json:"category"`
-	SubCategory                  string              `json:"subCategory"`
-	LastUpdaterUserID            string              `json:"lastUpdaterUserId"`
-	LifecycleState               string              `json:"lifecycleState"`
-	Version                      string              `json:"version"`
-	ToscaModelURL                string              `json:"toscaModelURL"`
-	Artifacts                    struct{}            `json:"artifacts"`
-	Attributes                   []string            `json:"attributes"`
-	Capabilities                 struct{}            `json:"capabilities"`
-	Categories                   []Category          `json:"categories"`
-	ComponentInstances           []ComponentInstance `json:"componentInstances"`
-	ComponentInstancesAttributes struct{}            `json:"componentInstancesAttributes"`
-	ComponentInstancesProperties struct{}            `json:"componentInstancesProperties"`
-	ComponentType                string              `json:"componentType"`
-	ContactID                    string              `json:"contactId"`
-	CsarUUID                     string              `json:"csarUUID"`
-	CsarVersion                  string              `json:"csarVersion"`
-	DeploymentArtifacts          struct{}            `json:"deploymentArtifacts"`
-	Description                  string              `json:"description"`
-	Icon                         string              `json:"icon"`
-	Properties                   []Property          `json:"properties"`
-	Requirements                 struct{}            `json:"requirements"`
-	Tags                         []string            `json:"tags"`
-	ToscaArtifacts               struct{}            `json:"toscaArtifacts"`
-	VendorName                   string              `json:"vendorName"`
-	VendorRelease                string              `json:"vendorRelease"`
-	DistributionStatus           string              `json:"distributionStatus"`
-	DistributionID               string              `json:"distributionID"`
-	Inputs                       []Input
+	ID                           string                          `json:"uuid"`
+	InvariantID                  string                          `json:"invariantUUID"`
+	UniqueID                     string                          `json:"uniqueId"`
+	ResourceType                 string                          `json:"resourceType"`
+	Name                         string                          `json:"name"`
+	Category                     string                          `json:"category"`
+	SubCategory                  string                          `json:"subCategory"`
+	LastUpdaterUserID            string                          `json:"lastUpdaterUserId"`
+	LifecycleState               string                          `json:"lifecycleState"`
+	Version                      string                          `json:"version"`
+	ToscaModelURL                string                          `json:"toscaModelURL"`
+	Artifacts                    map[string]Artifact             `json:"artifacts"`
+	Attributes                   []string                        `json:"attributes"`
+	Capabilities                 struct{}                        `json:"capabilities"`
+	Categories                   []Category                      `json:"categories"`
+	ComponentInstances           []ComponentInstance             `json:"componentInstances"`
+	ComponentInstancesAttributes struct{}                        `json:"componentInstancesAttributes"`
+	ComponentInstancesProperties map[string][]Property           `json:"componentInstancesProperties"`
+	ComponentInstancesInputs     map[string][]Input              `json:"componentInstancesInputs"`
+	ComponentInstancesRelations  []RequirementCapabilityRelation `json:"componentInstancesRelations,omitempty"`
+	ComponentType                string                          `json:"componentType"`
+	ContactID                    string                          `json:"contactId"`
+	CsarUUID                     string                          `json:"csarUUID"`
+	CsarVersion                  string                          `json:"csarVersion"`
+	DeploymentArtifacts          map[string]Artifact             `json:"deploymentArtifacts"`
+	Description                  string                          `json:"description"`
+	Icon                         string                          `json:"icon"`
+	Properties                   []Property                      `json:"properties"`
+	Requirements                 struct{}                        `json:"requirements"`
+	Tags                         []string                        `json:"tags"`
+	ToscaArtifacts               map[string]Artifact             `json:"toscaArtifacts"`
+	ServiceAPIArtifacts          map[string]Artifact             `json:"serviceApiArtifacts"`
+	VendorName                   string                          `json:"vendorName"`
+	VendorRelease                string                          `json:"vendorRelease"`
+	DistributionStatus           string                          `json:"distributionStatus"`
+	DistributionID               string                          `json:"distributionID"`
+	DistributerUserID            string                          `json:"-"`
+	Distributions                []Distribution                  `json:"-"`
+	DistributionDecision         *DistributionDecision           `json:"distributionDecision,omitempty"`
+	Archived                     bool                            `json:"archived"`
+	Model                        string                          `json:"model,omitempty"`
+	Normative                    bool                            `json:"normative,omitempty"`
+	ToscaResourceName            string                          `json:"toscaResourceName,omitempty"`
+	ArchiveTime                  int64                           `json:"archiveTime,omitempty"`
+	Inputs                       []Input                         `json:"inputs"`
+	Groups                       []Group                         `json:"groups"`
+	PayloadData                  string                          `json:"payloadData,omitempty"`
+	PayloadName                  string                          `json:"payloadName,omitempty"`
 }
 
 // ResourceList is the way to return Resources in SDC via DeepLoad
@@ -128,6 +116,15 @@ type ResourceList struct {
 	Services  []Resource `json:"services"`
 }
 
+// DistributionDecision is the last approval or rejection of the
+// distribution of a component, with the remarks of the governor
+type DistributionDecision struct {
+	Action      string `json:"action"`
+	UserID      string `json:"userId"`
+	UserRemarks string `json:"userRemarks"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
 // ActionBody yolo
 type ActionBody struct {
 	UserRemarks string `json:"userRemarks"`
@@ -150,6 +147,8 @@ type DistributionIDResult struct {
 	DistributionID    string `json:"distributionID"`
 	UserID            string `json:"userId"`
 	DeployementStatus string `json:"deployementStatus"`
+	Timestamp         string `json:"timestamp,omitempty"`
+	EnvName           string `json:"envName,omitempty"`
 }
 
 // DistributionIDList format
@@ -171,94 +170,60 @@ type DistributionStatusList struct {
 	DistributionStatusList []DistributionStatus `json:"distributionStatusList"`
 }
 
-// NewUploadResult format
-type NewUploadResult struct {
-	Description  string `json:"description"`
-	ArtifactType string `json:"artifactType"`
-	ArtifactName string `json:"artifactName"`
-}
+var resourceList []Resource
+var distributionList []DistributionStatus
 
-// Property format
-type Property struct {
-	Name           string `json:"name"`
-	Value          string `json:"value"`
-	Type           string `json:"type"`
-	UniqueID       string `json:"uniqueId"`
-	ParentUniqueID string `json:"parentUniqueId"`
+// generateInitialResourceList creates the normative resources of the models
+// and the ones found in CSAR_DIR
+func generateInitialResourceList() error {
+	resourceList = nil
+	addNormativeTypes(defaultModel)
+	for _, m := range modelList {
+		addNormativeTypes(m)
+	}
+	return loadCsarDirectory()
 }
 
-// Input format
-type Input struct {
-	Name     string `json:"name"`
-	Value    string `json:"value"`
-	Type     string `json:"type"`
-	UniqueID string `json:"uniqueId"`
+// initResourceCollections replaces the missing collections of a resource by
+// empty ones, so that they are returned as {} and not null
+func initResourceCollections(r *Resource) {
+	if r.Artifacts == nil {
+		r.Artifacts = map[string]Artifact{}
+	}
+	if r.DeploymentArtifacts == nil {
+		r.DeploymentArtifacts = map[string]Artifact{}
+	}
+	if r.ToscaArtifacts == nil {
+		r.ToscaArtifacts = map[string]Artifact{}
+	}
+	if r.ServiceAPIArtifacts == nil {
+		r.ServiceAPIArtifacts = map[string]Artifact{}
+	}
+	if r.ComponentInstancesProperties == nil {
+		r.ComponentInstancesProperties = map[string][]Property{}
+	}
+	if r.ComponentInstancesInputs == nil {
+		r.ComponentInstancesInputs = map[string][]Input{}
+	}
+	if r.Groups == nil {
+		r.Groups = []Group{}
+	}
 }
 
-var resourceList []Resource
-var distributionList []DistributionStatus
-
-func generateInitialResourceList() {
-	resourceList = nil
-	resourceList = append(resourceList, Resource{
-		ID:                "6c4952d2-0ecc-4697-a039-d9766565feae",
-		InvariantID:       "803cbaf5-deea-4022-a731-709d285435d6",
-		UniqueID:          "1e6e90ec-632a-492f-9511-f2787a2befaf",
-		ResourceType:      "Configuration",
-		Name:              "VLAN Network Receptor Configuration",
-		Category:          "Configuration",
-		SubCategory:       "Configuration",
-		LastUpdaterUserID: "jh0003",
-		LifecycleState:    "CERTIFIED",
-		Version:           "1.0",
-		ToscaModelURL:     "/sdc/v1/catalog/resources/6c4952d2-0ecc-4697-a039-d9766565feae/toscaModel",
-	})
-	resourceList = append(resourceList, Resource{
-		ID:                "85a9a912-b0ca-4cc9-9dc4-a480546ef93b",
-		InvariantID:       "2df7615c-38f5-45e2-ac40-f9a8f97baec2",
-		UniqueID:          "1e6e90ec-632a-492f-9511-f2787a2befaf",
-		ResourceType:      "CP",
-		Name:              "contrailV2VLANSubInterfaceV2",
-		Category:          "Generic",
-		SubCategory:       "Network Elements",
-		LastUpdaterUserID: "jh0003",
-		LifecycleState:    "CERTIFIED",
-		Version:           "1.0",
-		ToscaModelURL:     "/sdc/v1/catalog/resources/85a9a912-b0ca-4cc9-9dc4-a480546ef93b/toscaModel",
-	})
-	resourceList = append(resourceList, Resource{
-		ID:                "7c6b6644-590d-4e60-84d7-0dfba3ad4694",
-		InvariantID:       "1e6e90ec-632a-492f-9511-f2787a2bef9f",
-		UniqueID:          "1e6e90ec-632a-492f-9511-f2787a2befaf",
-		ResourceType:      "VFC",
-		Name:              "VDU Compute",
-		Category:          "Generic",
-		SubCategory:       "Infrastructure",
-		LastUpdaterUserID: "jh0003",
-		LifecycleState:    "CERTIFIED",
-		Version:           "1.0",
-		ToscaModelURL:     "/sdc/v1/catalog/resources/7c6b6644-590d-4e60-84d7-0dfba3ad4694/toscaModel",
-	})
-	resourceList = append(resourceList, Resource{
-		ID:                "9391354f-8f25-462d-b331-841e6cc5c851",
-		InvariantID:       "85cd3f14-cb9c-4a28-811b-d076e9a48303",
-		UniqueID:          "1e6e90ec-632a-492f-9511-f2787a2befaf",
-		ResourceType:      "VFC",
-		Name:              "Cp",
-		Category:          "Generic",
-		SubCategory:       "Infrastructure",
-		LastUpdaterUserID: "jh0003",
-		LifecycleState:    "CERTIFIED",
-		Version:           "1.0",
-		ToscaModelURL:     "/sdc/v1/catalog/resources/9391354f-8f25-462d-b331-841e6cc5c851/toscaModel",
-	})
+func findResourceIndex(uniqueID string) int {
+	for i, r := range resourceList {
+		if r.UniqueID == uniqueID {
+			return i
+		}
+	}
+	return -1
 }
 
 func getResources(c echo.Context) error {
 	resourceType := c.QueryParam("resourceType")
 	resources := []ResourceLight{}
 	for _, r := range resourceList {
-		if (r.ComponentType != "SERVICE") &&
+		if (r.ComponentType != "SERVICE") && !r.Archived && inModel(c, r) &&
 			((resourceType == "") || (r.ResourceType == resourceType)) {
 			resources = append(resources, ResourceLight{
 				ID:                r.ID,
@@ -271,6 +236,7 @@ func getResources(c echo.Context) error {
 				LifecycleState:    r.LifecycleState,
 				Version:           r.Version,
 				ToscaModelURL:     r.ToscaModelURL,
+				Model:             r.Model,
 			})
 		}
 	}
@@ -286,7 +252,7 @@ func getResources(c echo.Context) error {
 func getServices(c echo.Context) error {
 	resources := []ResourceLight{}
 	for _, r := range resourceList {
-		if r.ComponentType == "SERVICE" {
+		if r.ComponentType == "SERVICE" && !r.Archived && inModel(c, r) {
 			resources = append(resources, ResourceLight{
 				ID:                 r.ID,
 				InvariantID:        r.InvariantID,
@@ -299,6 +265,7 @@ func getServices(c echo.Context) error {
 				Version:            r.Version,
 				ToscaModelURL:      r.ToscaModelURL,
 				DistributionStatus: r.DistributionStatus,
+				Model:              r.Model,
 			})
 		}
 	}
@@ -312,17 +279,60 @@ func getServices(c echo.Context) error {
 }
 
 func postResources(c echo.Context) error {
+	user, ok, err := authorizeUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return err
+	}
 	resource := new(Resource)
 	if err := c.Bind(resource); err != nil {
 		return err
 	}
-	for _, r := range resourceList {
-		if resource.Name == r.Name && r.ResourceType == resource.ResourceType {
-			return c.JSON(http.StatusBadRequest, SdcError{
-				Message:   "Resource of same Name and ResourceType exists",
-				ErrorCode: "SVC3642",
-				Status:    "Exists"})
+	if status, sdcError := registerComponent(resource, user); sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	return c.JSON(http.StatusCreated, resource)
+}
+
+// registerComponent adds a new resource or service created by user to the
+// catalog, imported from the CSAR in its payloadData if any. It returns the
+// validation error with its HTTP status.
+func registerComponent(resource *Resource, user User) (int, *SdcError) {
+	var csar *Csar
+	payloadName := resource.PayloadName
+	if resource.PayloadData != "" {
+		var sdcError *SdcError
+		csar, sdcError = decodeCsarPayload(resource.PayloadData, payloadName)
+		if sdcError != nil {
+			return http.StatusBadRequest, sdcError
+		}
+		resource.PayloadData = ""
+		resource.PayloadName = ""
+		if isServiceCsar(csar) != (resource.ComponentType == "SERVICE") {
+			return http.StatusBadRequest, &SdcError{
+				Message:   "CSAR " + payloadName + " is invalid. Reason - unexpected component type.",
+				ErrorCode: "SVC4587",
+				Status:    "Invalid Content"}
+		}
+		importCsarMetadata(resource, csar)
+	}
+	var onboarded *OnboardedPackage
+	if resource.CsarUUID != "" && csar == nil {
+		onboarded = vspPackage(resource.CsarUUID)
+		if onboarded != nil && resource.ResourceType == "" {
+			resource.ResourceType = onboarded.ResourceType
 		}
+		if onboarded != nil && resource.ResourceType != onboarded.ResourceType {
+			return http.StatusBadRequest, &SdcError{
+				Message:   "CSAR " + resource.CsarUUID + " is invalid. Reason - unexpected resource type " + resource.ResourceType + ", the package describes a " + onboarded.ResourceType + ".",
+				ErrorCode: "SVC4587",
+				Status:    "Invalid Content"}
+		}
+	}
+	if status, sdcError := validateComponent(resource); sdcError != nil {
+		return status, sdcError
+	}
+	if sdcError := validateResourceCategory(resource); sdcError != nil {
+		return http.StatusBadRequest, sdcError
 	}
 	resource.ID = uuid.NewV4().String()
 	resource.InvariantID = uuid.NewV4().String()
@@ -330,10 +340,21 @@ func postResources(c echo.Context) error {
 	resource.Version = "0.1"
 	resource.LifecycleState = "NOT_CERTIFIED_CHECKOUT"
 	resource.DistributionStatus = "DISTRIBUTION_NOT_APPROVED"
-
+	setLastUpdater(resource, user)
+	resource.ToscaModelURL = "/sdc/v1/catalog/" + catalogCollection(*resource) + "/" + resource.ID + "/toscaModel"
+	initResourceCollections(resource)
+	if onboarded != nil {
+		importPackageModules(resource, onboarded)
+		importSoftwareVersions(resource, onboarded)
+	}
+	if csar != nil {
+		if resource.ComponentType == "SERVICE" {
+			importCsarResources(csar, resource.Model)
+		}
+		importCsarTopology(resource, csar, payloadName)
+	}
 	resourceList = append(resourceList, *resource)
-
-	return c.JSON(http.StatusCreated, resource)
+	return http.StatusCreated, nil
 }
 
 func postResourceAction(c echo.Context) error {
@@ -343,42 +364,153 @@ func postResourceAction(c echo.Context) error {
 	if err := c.Bind(actionBody); err != nil {
 		return err
 	}
-	for i, r := range resourceList {
-		if r.UniqueID == resourceID {
-			if r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" && action == "Certify" {
-				resourceList[i].Version = "1.0"
-				resourceList[i].LifecycleState = "CERTIFIED"
-				return c.JSON(http.StatusCreated, resourceList[i])
-			}
-			if r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" && action == "checkin" {
-				resourceList[i].LifecycleState = "NOT_CERTIFIED_CHECKIN"
-				return c.JSON(http.StatusOK, resourceList[i])
-			}
-			if r.LifecycleState == "NOT_CERTIFIED_CHECKIN" && action == "Certify" {
-				resourceList[i].LifecycleState = "CERTIFIED"
-				resourceList[i].Version = "1.0"
-				resourceList[i].DistributionStatus = "DISTRIBUTION_APPROVED"
-				return c.JSON(http.StatusOK, resourceList[i])
-			}
-			if r.LifecycleState == "CERTIFIED" &&
-				r.DistributionStatus == "DISTRIBUTION_APPROVED" &&
-				action == "activate" {
-				resourceList[i].DistributionStatus = "DISTRIBUTED"
-				return c.JSON(http.StatusOK, resourceList[i])
-			}
-			return c.JSON(http.StatusBadRequest, SdcError{
-				Message:   "Cannot perform this action",
-				ErrorCode: "SVC3642",
-				Status:    "Bad Action"})
+	user, ok, err := authorizeUser(c, actionRoles(action)...)
+	if !ok {
+		return err
+	}
+	if !routeAction(c, action) {
+		return c.JSON(newSdcError("INVALID_CONTENT"))
+	}
+	if (action == "approve" || action == "reject") && strings.TrimSpace(actionBody.UserRemarks) == "" {
+		status, sdcError := newSdcError("COMPONENT_MISSING_USER_REMARKS")
+		return c.JSON(status, sdcError)
+	}
+	i := findResourceIndex(resourceID)
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	if (action == "approve" || action == "reject") && resourceList[i].ComponentType != "SERVICE" {
+		return c.JSON(newSdcError("INVALID_CONTENT"))
+	}
+	if action == "activate" {
+		env := c.Param("env")
+		if env == "" {
+			env = defaultDistributionEnv
+		}
+		if status, sdcError := distributeComponent(i, env, user); sdcError != nil {
+			return c.JSON(status, sdcError)
 		}
+		return c.JSON(http.StatusOK, resourceList[i])
 	}
-	return c.JSON(http.StatusNotFound, SdcError{
-		Message:   "Resource not found",
-		ErrorCode: "SVC4642",
-		Status:    "Not Found"})
+	j, status, sdcError := changeLifecycleState(i, action, user)
+	if sdcError != nil {
+		return c.JSON(status, sdcError)
+	}
+	if action == "approve" || action == "reject" {
+		resourceList[j].DistributionDecision = &DistributionDecision{
+			Action:      action,
+			UserID:      user.UserID,
+			UserRemarks: actionBody.UserRemarks,
+			Timestamp:   time.Now().UnixNano() / 1000000,
+		}
+	}
+	return c.JSON(status, resourceList[j])
+}
+
+// changeLifecycleState performs a lifecycle or approval action of user
+// on the component i. It returns the index of the resulting component, a new
+// version when a certified one is checked out, and the response status.
+func changeLifecycleState(i int, action string, user User) (int, int, *SdcError) {
+	r := resourceList[i]
+	if r.Archived {
+		return i, http.StatusBadRequest, archivedComponentError(r)
+	}
+	if r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" && action == "checkin" {
+		if sdcError := checkLock(r, user); sdcError != nil {
+			return i, http.StatusForbidden, sdcError
+		}
+	}
+	if r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" && action == "Certify" {
+		resourceList[i].Version = nextMajorVersion(r.Version)
+		resourceList[i].LifecycleState = "CERTIFIED"
+		resourceList[i].DistributionStatus = certifiedDistributionStatus()
+		setLastUpdater(&resourceList[i], user)
+		return i, http.StatusCreated, nil
+	}
+	if r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" && action == "checkin" {
+		resourceList[i].LifecycleState = "NOT_CERTIFIED_CHECKIN"
+		setLastUpdater(&resourceList[i], user)
+		return i, http.StatusOK, nil
+	}
+	if r.LifecycleState == "NOT_CERTIFIED_CHECKIN" && action == "checkout" {
+		resourceList[i].LifecycleState = "NOT_CERTIFIED_CHECKOUT"
+		setLastUpdater(&resourceList[i], user)
+		return i, http.StatusOK, nil
+	}
+	if r.LifecycleState == "CERTIFIED" && action == "checkout" && isHighestVersion(r) {
+		j := newResourceVersion(i)
+		setLastUpdater(&resourceList[j], user)
+		return j, http.StatusOK, nil
+	}
+	if r.LifecycleState == "NOT_CERTIFIED_CHECKIN" && action == "Certify" {
+		resourceList[i].LifecycleState = "CERTIFIED"
+		resourceList[i].Version = nextMajorVersion(r.Version)
+		resourceList[i].DistributionStatus = certifiedDistributionStatus()
+		setLastUpdater(&resourceList[i], user)
+		return i, http.StatusOK, nil
+	}
+	if r.LifecycleState == "CERTIFIED" && action == "approve" &&
+		(r.DistributionStatus == "DISTRIBUTION_NOT_APPROVED" || r.DistributionStatus == "DISTRIBUTION_REJECTED") {
+		resourceList[i].DistributionStatus = "DISTRIBUTION_APPROVED"
+		return i, http.StatusOK, nil
+	}
+	if r.LifecycleState == "CERTIFIED" && action == "reject" &&
+		(r.DistributionStatus == "DISTRIBUTION_NOT_APPROVED" || r.DistributionStatus == "DISTRIBUTION_APPROVED") {
+		resourceList[i].DistributionStatus = "DISTRIBUTION_REJECTED"
+		return i, http.StatusOK, nil
+	}
+	return i, http.StatusBadRequest, &SdcError{
+		Message:   "Cannot perform this action",
+		ErrorCode: "SVC3642",
+		Status:    "Bad Action"}
+}
+
+// certifiedDistributionStatus is the distribution status of a component
+// when it gets certified: not approved, the distribution waiting for the
+// approval of a governor, unless DISTRIBUTION_APPROVAL_REQUIRED is false
+func certifiedDistributionStatus() string {
+	if required, err := strconv.ParseBool(os.Getenv("DISTRIBUTION_APPROVAL_REQUIRED")); err == nil && !required {
+		return "DISTRIBUTION_APPROVED"
+	}
+	return "DISTRIBUTION_NOT_APPROVED"
+}
+
+// routeAction tells whether action may be requested on the route of c:
+// approve and reject through the distribution state of a service, activate
+// through the distribution to an environment and the others through the
+// lifecycle state
+func routeAction(c echo.Context, action string) bool {
+	switch {
+	case strings.Contains(c.Path(), "/distribution-state/"):
+		return action == "approve" || action == "reject"
+	case strings.Contains(c.Path(), "/distribution/"):
+		return action == "activate"
+	}
+	return action != "approve" && action != "reject" && action != "activate"
+}
+
+// actionRoles returns the roles allowed to perform a lifecycle or
+// distribution action
+func actionRoles(action string) []string {
+	switch action {
+	case "Certify":
+		return []string{"TESTER"}
+	case "activate":
+		return []string{"OPS"}
+	case "approve", "reject":
+		return []string{"GOVERNOR"}
+	}
+	return []string{"DESIGNER", "ADMIN"}
 }
 
 func postAddResourceToService(c echo.Context) error {
+	user, ok, err := authorizeUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return err
+	}
 	resourceID := c.Param("resourceID")
 	resourceAdd := new(ResourceAdd)
 	if err := c.Bind(resourceAdd); err != nil {
@@ -386,24 +518,26 @@ func postAddResourceToService(c echo.Context) error {
 	}
 	for i, r := range resourceList {
 		if r.UniqueID == resourceID {
+			if r.Archived {
+				return c.JSON(http.StatusBadRequest, archivedComponentError(r))
+			}
+			if sdcError := checkLock(r, user); sdcError != nil && r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" {
+				return c.JSON(http.StatusForbidden, sdcError)
+			}
 			if r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" {
 				for _, rr := range resourceList {
-					if rr.UniqueID == resourceAdd.UniqueID &&
+					if rr.UniqueID == resourceAdd.UniqueID && !rr.Archived &&
 						rr.UniqueID == resourceAdd.ComponentUID &&
 						rr.Name == resourceAdd.Name &&
 						rr.Version == resourceAdd.ComponentVersion &&
 						rr.ResourceType == resourceAdd.OriginType {
-						if rr.ResourceType == "VF" {
-							ci := ComponentInstance{
-								UniqueID:         uuid.NewV4().String(),
-								Name:             resourceAdd.Name,
-								ComponentName:    resourceAdd.Name,
-								OriginType:       "VF",
-								ComponentVersion: "1.0",
-							}
+						if rr.ResourceType == "VF" || rr.ResourceType == "PNF" || rr.Normative {
+							ci := newComponentInstance(resourceAdd.Name, rr)
 							resourceList[i].ComponentInstances = append(r.ComponentInstances, ci)
+							addInstanceProperties(&resourceList[i], ci, rr)
 						}
-						return c.JSON(http.StatusCreated, r)
+						setLastUpdater(&resourceList[i], user)
+						return c.JSON(http.StatusCreated, resourceList[i])
 					}
 				}
 			}
@@ -421,21 +555,40 @@ func postAddResourceToService(c echo.Context) error {
 		Status:    "Not Found"})
 }
 
+// distributerName is the way SDC shows the user who activated a distribution
+func distributerName(userID string) string {
+	if j := findUserIndex(userID); j >= 0 {
+		return userList[j].displayName()
+	}
+	return "Oper P(op0001)"
+}
+
 func getDistribution(c echo.Context) error {
 	resourceID := c.Param("resourceID")
 	for i, r := range resourceList {
 		if r.ID == resourceID {
-			distributionIDResult := new(DistributionIDResult)
-			if r.DistributionStatus == "DISTRIBUTED" {
-				if len(r.DistributionID) < 1 {
-					resourceList[i].DistributionID = uuid.NewV4().String()
+			distributionIDList := new(DistributionIDList)
+			for _, d := range r.Distributions {
+				distributionIDList.DistributionStatusOfServiceList = append(distributionIDList.DistributionStatusOfServiceList, DistributionIDResult{
+					DistributionID:    d.ID,
+					UserID:            distributerName(d.UserID),
+					DeployementStatus: "Distributed",
+					Timestamp:         strconv.FormatInt(d.Timestamp, 10),
+					EnvName:           d.EnvName,
+				})
+			}
+			if len(r.Distributions) == 0 {
+				distributionIDResult := new(DistributionIDResult)
+				if r.DistributionStatus == "DISTRIBUTED" {
+					if len(r.DistributionID) < 1 {
+						resourceList[i].DistributionID = uuid.NewV4().String()
+					}
+					distributionIDResult.DeployementStatus = "Distributed"
+					distributionIDResult.UserID = distributerName(r.DistributerUserID)
+					distributionIDResult.DistributionID = resourceList[i].DistributionID
 				}
-				distributionIDResult.DeployementStatus = "Distributed"
-				distributionIDResult.UserID = "Oper P(op0001)"
-				distributionIDResult.DistributionID = resourceList[i].DistributionID
+				distributionIDList.DistributionStatusOfServiceList = append(distributionIDList.DistributionStatusOfServiceList, *distributionIDResult)
 			}
-			distributionIDList := new(DistributionIDList)
-			distributionIDList.DistributionStatusOfServiceList = append(distributionIDList.DistributionStatusOfServiceList, *distributionIDResult)
 			return c.JSON(http.StatusOK, distributionIDList)
 		}
 	}
@@ -445,10 +598,18 @@ func getDistribution(c echo.Context) error {
 		Status:    "Not Found"})
 }
 
+// getDistributionList returns the statuses of the consumers notified of a
+// distribution. The distributions which were not activated through the mock
+// return the sample statuses.
 func getDistributionList(c echo.Context) error {
 	distributionID := c.Param("distributionID")
 	for _, r := range resourceList {
-		if r.DistributionID == distributionID {
+		for _, distribution := range r.Distributions {
+			if distribution.ID == distributionID {
+				return c.JSON(http.StatusOK, DistributionStatusList{DistributionStatusList: distribution.Statuses})
+			}
+		}
+		if r.DistributionID == distributionID && len(r.Distributions) == 0 {
 			d := new(DistributionStatusList)
 			d.DistributionStatusList = distributionList
 			return c.JSON(http.StatusOK, d)
@@ -460,22 +621,6 @@ func getDistributionList(c echo.Context) error {
 		Status:    "Not Found"})
 }
 
-func getAllResources(c echo.Context) error {
-
-	var listResources []Resource
-	var listServices []Resource
-
-	for _, r := range resourceList {
-		if r.ComponentType != "SERVICE" {
-			listResources = append(listResources, r)
-		} else {
-			listServices = append(listServices, r)
-		}
-	}
-	list := &ResourceList{listResources, listServices}
-	return c.JSON(http.StatusOK, list)
-}
-
 func getServiceUniqueIdentifier(c echo.Context) error {
 	resourceID := c.Param("resourceID")
 	for _, r := range resourceList {
@@ -491,24 +636,33 @@ func getServiceUniqueIdentifier(c echo.Context) error {
 }
 
 func uploadTcaArtifact(c echo.Context) error {
+	user, ok, err := authorizeUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return err
+	}
 	resourceID := c.Param("resourceID")
 	vfID := c.Param("vfID")
-	for _, r := range resourceList {
+	for i, r := range resourceList {
 		if r.UniqueID == resourceID {
+			if r.Archived {
+				return c.JSON(http.StatusBadRequest, archivedComponentError(r))
+			}
+			if sdcError := checkLock(r, user); sdcError != nil && r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" {
+				return c.JSON(http.StatusForbidden, sdcError)
+			}
 			if r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" {
-				for _, cc := range r.ComponentInstances {
+				for j, cc := range r.ComponentInstances {
 					if cc.UniqueID == vfID {
-						newArtifact := new(ArtifactAdd)
-						if err := c.Bind(newArtifact); err != nil {
+						body := new(ArtifactAdd)
+						if err := c.Bind(body); err != nil {
 							return err
 						}
-						cc.DeploymentArtifacts = append(cc.DeploymentArtifacts, *newArtifact)
-						NewUploadResult := NewUploadResult{
-							Description:  newArtifact.Description,
-							ArtifactType: newArtifact.ArtifactType,
-							ArtifactName: newArtifact.ArtifactName,
+						artifact, sdcError := addInstanceArtifact(&resourceList[i], j, *body)
+						if sdcError != nil {
+							return c.JSON(http.StatusBadRequest, sdcError)
 						}
-						return c.JSON(http.StatusCreated, NewUploadResult)
+						setLastUpdater(&resourceList[i], user)
+						return c.JSON(http.StatusCreated, artifact)
 					}
 				}
 			}
@@ -524,78 +678,106 @@ func uploadTcaArtifact(c echo.Context) error {
 		Status:    "Not Found"})
 }
 
-func postResourceProperties(c echo.Context) error {
-	resourceID := c.Param("resourceID")
-	var bodyBytes []byte
-	if c.Request().Body != nil {
-		bodyBytes, _ = ioutil.ReadAll(c.Request().Body)
-	}
-	var dat map[string]interface{}
-	if err := json.Unmarshal(bodyBytes, &dat); err != nil {
-		return err
-	}
-	for i, r := range resourceList {
-		if r.UniqueID == resourceID {
-			for key := range dat {
-				propertyBody := new(Property)
-				propertyBody.Name = dat[key].(map[string]interface{})["name"].(string)
-				propertyBody.Type = dat[key].(map[string]interface{})["type"].(string)
-				resourceList[i].Properties = append(r.Properties, *propertyBody)
-			}
-			return c.JSON(http.StatusOK, "")
-		}
-	}
-	return c.JSON(http.StatusNotFound, "")
-}
-
-func getResourceProperties(c echo.Context) error {
-	resourceID := c.Param("resourceID")
-	for _, r := range resourceList {
-		if r.UniqueID == resourceID {
-			return c.JSON(http.StatusOK, map[string][]Property{
-				"properties": r.Properties,
-			})
-		}
+// filteredData returns the part of a component selected by an include
+// parameter of filteredDataByParams
+func filteredData(r Resource, include string) (interface{}, bool) {
+	switch include {
+	case "inputs":
+		return nonNil(r.Inputs, []Input{}), true
+	case "outputs":
+		return []Input{}, true
+	case "properties":
+		return nonNil(r.Properties, []Property{}), true
+	case "componentInstances":
+		return nonNil(r.ComponentInstances, []ComponentInstance{}), true
+	case "componentInstancesRelations":
+		return nonNil(r.ComponentInstancesRelations, []RequirementCapabilityRelation{}), true
+	case "componentInstancesProperties":
+		return r.ComponentInstancesProperties, true
+	case "componentInstancesInputs":
+		return r.ComponentInstancesInputs, true
+	case "groups":
+		return r.Groups, true
+	case "policies":
+		return []struct{}{}, true
+	case "artifacts":
+		return r.Artifacts, true
+	case "deploymentArtifacts":
+		return r.DeploymentArtifacts, true
+	case "toscaArtifacts":
+		return r.ToscaArtifacts, true
+	case "requirements":
+		return r.Requirements, true
+	case "capabilities":
+		return r.Capabilities, true
+	case "interfaces":
+		return struct{}{}, true
+	case "metadata":
+		return map[string]interface{}{
+			"uniqueId":          r.UniqueID,
+			"UUID":              r.ID,
+			"invariantUUID":     r.InvariantID,
+			"name":              r.Name,
+			"normalizedName":    normalizeComponentInstanceName(r.Name),
+			"systemName":        toscaName(r.Name),
+			"version":           r.Version,
+			"description":       r.Description,
+			"lifecycleState":    r.LifecycleState,
+			"componentType":     r.ComponentType,
+			"resourceType":      r.ResourceType,
+			"categories":        r.Categories,
+			"tags":              r.Tags,
+			"icon":              r.Icon,
+			"contactId":         r.ContactID,
+			"vendorName":        r.VendorName,
+			"vendorRelease":     r.VendorRelease,
+			"csarUUID":          r.CsarUUID,
+			"csarVersion":       r.CsarVersion,
+			"lastUpdaterUserId": r.LastUpdaterUserID,
+			"toscaResourceName": componentToscaResourceName(r),
+			"model":             r.Model,
+			"allVersions":       componentVersions(r.InvariantID),
+			"isHighestVersion":  isHighestVersion(r),
+			"archived":          r.Archived,
+			"archiveTime":       r.ArchiveTime,
+		}, true
 	}
-	return c.JSON(http.StatusNotFound, "")
+	return nil, false
 }
 
-func postResourceInputs(c echo.Context) error {
-	resourceID := c.Param("resourceID")
-	inputBody := new(Input)
-	if err := c.Bind(inputBody); err != nil {
-		return err
+// nonNil returns empty instead of a nil slice, so that it is sent as [] and
+// not null
+func nonNil[T any](list []T, empty []T) []T {
+	if list == nil {
+		return empty
 	}
-	for i, r := range resourceList {
-		if r.UniqueID == resourceID {
-			resourceList[i].Inputs = append(r.Inputs, *inputBody)
-			return c.JSON(http.StatusOK, r.Inputs)
-		}
-	}
-	return c.JSON(http.StatusNotFound, "")
+	return list
 }
 
+// getResourcefilteredData returns the parts of a component selected by the
+// include parameters, which may be repeated or comma separated
 func getResourcefilteredData(c echo.Context) error {
-	paramType := c.QueryParam("include")
-	switch paramType {
-	case "inputs":
-		return getResourceInputs(c)
-	case "properties":
-		return getResourceProperties(c)
+	i := findResourceIndex(c.Param("resourceID"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
 	}
-	return errors.New("Invalid query param")
-}
-
-func getResourceInputs(c echo.Context) error {
-	resourceID := c.Param("resourceID")
-	for _, r := range resourceList {
-		if r.UniqueID == resourceID {
-			return c.JSON(http.StatusOK, map[string][]Input{
-				"inputs": r.Inputs,
-			})
+	result := map[string]interface{}{}
+	for _, param := range c.QueryParams()["include"] {
+		for _, include := range strings.Split(param, ",") {
+			data, found := filteredData(resourceList[i], include)
+			if !found {
+				return c.JSON(http.StatusBadRequest, SdcError{
+					Message:   "Invalid include parameter " + include + ".",
+					ErrorCode: "SVC4000",
+					Status:    "Invalid Content"})
+			}
+			result[include] = data
 		}
 	}
-	return c.JSON(http.StatusNotFound, "")
+	return c.JSON(http.StatusOK, result)
 }
 
 func generateDistributionStatusList() {
@@ -1350,29 +1532,3 @@ func generateDistributionStatusList() {
 		ErrorReason:    "null",
 	})
 }
-
-func getArtifactTypes(c echo.Context) error {
-	list := []string{"HEAT"}
-	return c.JSON(http.StatusOK, list)
-}
-
-func registerForDistribution(c echo.Context) error {
-	distributionRegistration := map[string]string{
-		"distrNotificationTopicName": "testName",
-		"distrStatusTopicName":       "testTopic",
-	}
-	return c.JSON(http.StatusOK, distributionRegistration)
-}
-
-func unRegisterForDistribution(c echo.Context) error {
-	return c.JSON(http.StatusOK, list.New())
-}
-
-func distributionKafkaData(c echo.Context) error {
-	kafkaData := map[string]string{
-		"kafkaBootStrapServer":       "localhost:43219",
-		"distrNotificationTopicName": "SDC-DIST-NOTIF-TOPIC",
-		"distrStatusTopicName":       "SDC-DIST-STATUS-TOPIC",
-	}
-	return c.JSON(http.StatusOK, kafkaData)
-}
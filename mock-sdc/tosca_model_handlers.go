@@ -0,0 +1,238 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// ArtifactMetadata describes an artifact in the external API
+type ArtifactMetadata struct {
+	ArtifactName        string `json:"artifactName"`
+	ArtifactType        string `json:"artifactType"`
+	ArtifactURL         string `json:"artifactURL"`
+	ArtifactDescription string `json:"artifactDescription"`
+	ArtifactTimeout     int    `json:"artifactTimeout"`
+	ArtifactChecksum    string `json:"artifactChecksum"`
+	ArtifactUUID        string `json:"artifactUUID"`
+	ArtifactVersion     string `json:"artifactVersion"`
+	ArtifactLabel       string `json:"artifactLabel"`
+	ArtifactGroupType   string `json:"artifactGroupType"`
+}
+
+// ResourceInstanceMetadata describes a component instance in the external API
+type ResourceInstanceMetadata struct {
+	ResourceInstanceName  string             `json:"resourceInstanceName"`
+	ResourceName          string             `json:"resourceName"`
+	ResourceInvariantUUID string             `json:"resourceInvariantUUID"`
+	ResourceVersion       string             `json:"resourceVersion"`
+	ResourceType          string             `json:"resoucreType"`
+	ResourceUUID          string             `json:"resourceUUID"`
+	Artifacts             []ArtifactMetadata `json:"artifacts"`
+}
+
+// ComponentMetadata describes a component in the external API
+type ComponentMetadata struct {
+	ID                string                     `json:"uuid"`
+	InvariantID       string                     `json:"invariantUUID"`
+	Name              string                     `json:"name"`
+	Version           string                     `json:"version"`
+	ToscaModelURL     string                     `json:"toscaModelURL"`
+	Category          string                     `json:"category"`
+	SubCategory       string                     `json:"subCategory,omitempty"`
+	ResourceType      string                     `json:"resourceType,omitempty"`
+	LifecycleState    string                     `json:"lifecycleState"`
+	LastUpdaterUserID string                     `json:"lastUpdaterUserId"`
+	Description       string                     `json:"description"`
+	ToscaResourceName string                     `json:"toscaResourceName"`
+	Artifacts         []ArtifactMetadata         `json:"artifacts"`
+	Resources         []ResourceInstanceMetadata `json:"resources,omitempty"`
+}
+
+// findResourceUUIDIndex looks a component up by the uuid used in the external API
+func findResourceUUIDIndex(id string) int {
+	for i, r := range resourceList {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// toscaModel returns the TOSCA CSAR of a component: the one it was imported
+// from if any, a generated one otherwise
+func toscaModel(r Resource) []byte {
+	if csar, found := r.ToscaArtifacts["assettoscacsar"]; found && len(csar.Payload) != 0 {
+		return csar.Payload
+	}
+	return buildCsar(r)
+}
+
+func artifactMetadata(baseURL string, a Artifact) ArtifactMetadata {
+	return ArtifactMetadata{
+		ArtifactName:        a.ArtifactName,
+		ArtifactType:        a.ArtifactType,
+		ArtifactURL:         baseURL + "/artifacts/" + a.ArtifactUUID,
+		ArtifactDescription: a.Description,
+		ArtifactTimeout:     a.Timeout,
+		ArtifactChecksum:    a.ArtifactChecksum,
+		ArtifactUUID:        a.ArtifactUUID,
+		ArtifactVersion:     a.ArtifactVersion,
+		ArtifactLabel:       a.ArtifactLabel,
+		ArtifactGroupType:   a.ArtifactGroupType,
+	}
+}
+
+func artifactsMetadata(baseURL string, groups ...map[string]Artifact) []ArtifactMetadata {
+	list := []ArtifactMetadata{}
+	for _, artifacts := range groups {
+		for _, label := range sortedKeys(artifacts) {
+			list = append(list, artifactMetadata(baseURL, artifacts[label]))
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].ArtifactGroupType < list[j].ArtifactGroupType })
+	return list
+}
+
+// instanceOrigin returns the component a component instance was created from
+func instanceOrigin(ci ComponentInstance) (Resource, bool) {
+	if i := findResourceIndex(ci.ComponentUID); ci.ComponentUID != "" && i >= 0 {
+		return resourceList[i], true
+	}
+	for _, r := range resourceList {
+		if r.ComponentType != "SERVICE" && r.Name == ci.ComponentName && r.Version == ci.ComponentVersion {
+			return r, true
+		}
+	}
+	return Resource{}, false
+}
+
+func componentMetadata(r Resource) ComponentMetadata {
+	baseURL := "/sdc/v1/catalog/" + catalogCollection(r) + "/" + r.ID
+	metadata := ComponentMetadata{
+		ID:                r.ID,
+		InvariantID:       r.InvariantID,
+		Name:              r.Name,
+		Version:           r.Version,
+		ToscaModelURL:     r.ToscaModelURL,
+		Category:          r.Category,
+		SubCategory:       r.SubCategory,
+		ResourceType:      r.ResourceType,
+		LifecycleState:    r.LifecycleState,
+		LastUpdaterUserID: r.LastUpdaterUserID,
+		Description:       r.Description,
+		ToscaResourceName: componentToscaResourceName(r),
+		Artifacts:         artifactsMetadata(baseURL, r.ToscaArtifacts, r.DeploymentArtifacts, r.Artifacts),
+	}
+	for _, ci := range r.ComponentInstances {
+		instance := ResourceInstanceMetadata{
+			ResourceInstanceName: ci.Name,
+			ResourceName:         ci.ComponentName,
+			ResourceVersion:      ci.ComponentVersion,
+			ResourceType:         ci.OriginType,
+			Artifacts: artifactsMetadata(baseURL+"/resourceInstances/"+normalizeComponentInstanceName(ci.Name),
+				ci.DeploymentArtifacts),
+		}
+		if origin, found := instanceOrigin(ci); found {
+			instance.ResourceUUID = origin.ID
+			instance.ResourceInvariantUUID = origin.InvariantID
+		}
+		metadata.Resources = append(metadata.Resources, instance)
+	}
+	return metadata
+}
+
+func findArtifactUUID(artifacts map[string]Artifact, artifactUUID string) (Artifact, bool) {
+	for _, a := range artifacts {
+		if a.ArtifactUUID == artifactUUID {
+			return a, true
+		}
+	}
+	return Artifact{}, false
+}
+
+func sendArtifact(c echo.Context, name string, payload []byte) error {
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=\""+name+"\"")
+	return c.Blob(http.StatusOK, echo.MIMEOctetStream, payload)
+}
+
+func getToscaModel(c echo.Context) error {
+	i := findResourceUUIDIndex(c.Param("uuid"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	r := resourceList[i]
+	return sendArtifact(c, strings.TrimSuffix(toscaTemplateName(r), "-template.yml")+"-csar.csar", toscaModel(r))
+}
+
+func getComponentMetadata(c echo.Context) error {
+	i := findResourceUUIDIndex(c.Param("uuid"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	return c.JSON(http.StatusOK, componentMetadata(resourceList[i]))
+}
+
+func getComponentArtifact(c echo.Context) error {
+	i := findResourceUUIDIndex(c.Param("uuid"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	r := resourceList[i]
+	for _, group := range []string{"DEPLOYMENT", "INFORMATIONAL", "TOSCA", "SERVICE_API"} {
+		if a, found := findArtifactUUID(*artifactGroup(&r, group), c.Param("artifactUUID")); found {
+			return sendArtifact(c, a.ArtifactName, a.Payload)
+		}
+	}
+	return c.JSON(http.StatusNotFound, SdcError{
+		Message:   "Artifact " + c.Param("artifactUUID") + " not found.",
+		ErrorCode: "SVC4128",
+		Status:    "Not Found"})
+}
+
+func getComponentInstanceArtifact(c echo.Context) error {
+	i := findResourceUUIDIndex(c.Param("uuid"))
+	if i < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	for _, ci := range resourceList[i].ComponentInstances {
+		if normalizeComponentInstanceName(ci.Name) != c.Param("instanceName") {
+			continue
+		}
+		if a, found := findArtifactUUID(ci.DeploymentArtifacts, c.Param("artifactUUID")); found {
+			return sendArtifact(c, a.ArtifactName, a.Payload)
+		}
+	}
+	return c.JSON(http.StatusNotFound, SdcError{
+		Message:   "Artifact " + c.Param("artifactUUID") + " not found.",
+		ErrorCode: "SVC4128",
+		Status:    "Not Found"})
+}
@@ -0,0 +1,207 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// ToscaType describes a TOSCA data, capability, relationship, policy or
+// group type
+type ToscaType struct {
+	DerivedFrom      string                    `yaml:"derived_from"`
+	Description      string                    `yaml:"description"`
+	Version          string                    `yaml:"version"`
+	Properties       map[string]ToscaParameter `yaml:"properties"`
+	ValidSourceTypes []string                  `yaml:"valid_source_types"`
+	ValidTargetTypes []string                  `yaml:"valid_target_types"`
+	Targets          []string                  `yaml:"targets"`
+	Members          []string                  `yaml:"members"`
+}
+
+// DataTypeDefinition is a TOSCA data type the way SDC returns it
+type DataTypeDefinition struct {
+	Name            string     `json:"name"`
+	UniqueID        string     `json:"uniqueId"`
+	DerivedFromName string     `json:"derivedFromName,omitempty"`
+	Description     string     `json:"description,omitempty"`
+	Model           string     `json:"model,omitempty"`
+	Properties      []Property `json:"properties"`
+}
+
+// TypeDefinition is a TOSCA capability, relationship, policy or group type
+// the way SDC returns it
+type TypeDefinition struct {
+	Type             string     `json:"type"`
+	UniqueID         string     `json:"uniqueId"`
+	DerivedFrom      string     `json:"derivedFrom,omitempty"`
+	Description      string     `json:"description,omitempty"`
+	Version          string     `json:"version,omitempty"`
+	ValidSourceTypes []string   `json:"validSourceTypes,omitempty"`
+	ValidTargetTypes []string   `json:"validTargetTypes,omitempty"`
+	Targets          []string   `json:"targets,omitempty"`
+	Members          []string   `json:"members,omitempty"`
+	Model            string     `json:"model,omitempty"`
+	Properties       []Property `json:"properties"`
+}
+
+// typeUniqueID is the id SDC gives to a type, prefixed by its model
+func typeUniqueID(model string, name string, kind string) string {
+	if model != "" {
+		return model + "_" + name + "." + kind
+	}
+	return name + "." + kind
+}
+
+// typeDerivesFrom tells whether the type name is base or derives from it
+func typeDerivesFrom(types map[string]ToscaType, name string, base string) bool {
+	for depth := 0; name != "" && depth <= len(types); depth++ {
+		if name == base {
+			return true
+		}
+		name = types[name].DerivedFrom
+	}
+	return false
+}
+
+// dataTypeProperty returns the definition of a field of a data type, which
+// may be inherited from the types it derives from
+func dataTypeProperty(types *ToscaTypeDefinitions, typeName string, field string) (ToscaParameter, bool) {
+	for depth := 0; depth <= len(types.DataTypes); depth++ {
+		dataType, found := types.DataTypes[typeName]
+		if !found {
+			break
+		}
+		if definition, found := dataType.Properties[field]; found {
+			return definition, true
+		}
+		typeName = dataType.DerivedFrom
+	}
+	return ToscaParameter{}, false
+}
+
+// scalarBase returns the scalar type a data type derives from, if any, as
+// tosca.datatypes.network.PortDef derives from integer
+func scalarBase(types *ToscaTypeDefinitions, typeName string) string {
+	for depth := 0; depth <= len(types.DataTypes); depth++ {
+		dataType, found := types.DataTypes[typeName]
+		if !found {
+			break
+		}
+		typeName = dataType.DerivedFrom
+	}
+	if containsString(toscaScalarTypes, typeName) {
+		return typeName
+	}
+	return ""
+}
+
+// requestedTypes returns the types of the model of the modelName parameter,
+// the default model if unset, or writes the error response
+func requestedTypes(c echo.Context) (*ToscaTypeDefinitions, string, error) {
+	model := c.QueryParam("modelName")
+	if _, found := findModel(model); !found {
+		status, sdcError := newSdcError("MODEL_NOT_FOUND", model)
+		return nil, model, c.JSON(status, sdcError)
+	}
+	return modelTypes(model), model, nil
+}
+
+func typeDefinition(model string, name string, kind string, t ToscaType) TypeDefinition {
+	uniqueID := typeUniqueID(model, name, kind)
+	return TypeDefinition{
+		Type:             name,
+		UniqueID:         uniqueID,
+		DerivedFrom:      t.DerivedFrom,
+		Description:      t.Description,
+		Version:          t.Version,
+		ValidSourceTypes: t.ValidSourceTypes,
+		ValidTargetTypes: t.ValidTargetTypes,
+		Targets:          t.Targets,
+		Members:          t.Members,
+		Model:            model,
+		Properties:       typeProperties(uniqueID, t.Properties),
+	}
+}
+
+func getDataTypes(c echo.Context) error {
+	types, model, err := requestedTypes(c)
+	if types == nil {
+		return err
+	}
+	dataTypes := map[string]DataTypeDefinition{}
+	for name, t := range types.DataTypes {
+		uniqueID := typeUniqueID(model, name, "datatype")
+		dataTypes[name] = DataTypeDefinition{
+			Name:            name,
+			UniqueID:        uniqueID,
+			DerivedFromName: t.DerivedFrom,
+			Description:     t.Description,
+			Model:           model,
+			Properties:      typeProperties(uniqueID, t.Properties),
+		}
+	}
+	return c.JSON(http.StatusOK, dataTypes)
+}
+
+func getCapabilityTypes(c echo.Context) error {
+	types, model, err := requestedTypes(c)
+	if types == nil {
+		return err
+	}
+	capabilityTypes := map[string]TypeDefinition{}
+	for name, t := range types.CapabilityTypes {
+		capabilityTypes[name] = typeDefinition(model, name, "capabilitytype", t)
+	}
+	return c.JSON(http.StatusOK, capabilityTypes)
+}
+
+func getRelationshipTypes(c echo.Context) error {
+	types, model, err := requestedTypes(c)
+	if types == nil {
+		return err
+	}
+	relationshipTypes := map[string]TypeDefinition{}
+	for name, t := range types.RelationshipTypes {
+		relationshipTypes[name] = typeDefinition(model, name, "relationshiptype", t)
+	}
+	return c.JSON(http.StatusOK, relationshipTypes)
+}
+
+func getPolicyTypes(c echo.Context) error {
+	types, model, err := requestedTypes(c)
+	if types == nil {
+		return err
+	}
+	policyTypes := []TypeDefinition{}
+	for _, name := range sortedKeys(types.PolicyTypes) {
+		policyTypes = append(policyTypes, typeDefinition(model, name, "policytype", types.PolicyTypes[name]))
+	}
+	return c.JSON(http.StatusOK, policyTypes)
+}
+
+func getGroupTypes(c echo.Context) error {
+	types, model, err := requestedTypes(c)
+	if types == nil {
+		return err
+	}
+	groupTypes := []TypeDefinition{}
+	for _, name := range sortedKeys(types.GroupTypes) {
+		groupTypes = append(groupTypes, typeDefinition(model, name, "grouptype", types.GroupTypes[name]))
+	}
+	return c.JSON(http.StatusOK, groupTypes)
+}
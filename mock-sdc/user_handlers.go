@@ -0,0 +1,283 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// User describes a user of SDC
+type User struct {
+	UserID        string `json:"userId"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+	FullName      string `json:"fullName"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	Status        string `json:"status"`
+	LastLoginTime int64  `json:"lastLoginTime"`
+}
+
+// UserRole is the body used to read or change the role of a user
+type UserRole struct {
+	Role string `json:"role"`
+}
+
+var userRoles = []string{"DESIGNER", "TESTER", "ADMIN", "OPS", "GOVERNOR"}
+
+var userList []User
+
+func newUser(userID string, firstName string, lastName string, role string) User {
+	return User{
+		UserID:    userID,
+		FirstName: firstName,
+		LastName:  lastName,
+		FullName:  firstName + " " + lastName,
+		Email:     userID + "@sdc.com",
+		Role:      role,
+		Status:    "ACTIVE",
+	}
+}
+
+func generateInitialUserList() {
+	userList = []User{
+		newUser("jh0003", "Jimmy", "Hendrix", "ADMIN"),
+		newUser("cs0008", "Carlos", "Santana", "DESIGNER"),
+		newUser("jm0007", "Joni", "Mitchell", "TESTER"),
+		newUser("op0001", "Oper", "P", "OPS"),
+		newUser("gv0001", "Gov", "V", "GOVERNOR"),
+	}
+}
+
+func findUserIndex(userID string) int {
+	for i, u := range userList {
+		if u.UserID == userID {
+			return i
+		}
+	}
+	return -1
+}
+
+// displayName is the way SDC shows who performed an operation
+func (u User) displayName() string {
+	return u.FullName + "(" + u.UserID + ")"
+}
+
+// userIDRequired tells whether requests without USER_ID are refused. They
+// are accepted with any role unless USER_ID_REQUIRED is true.
+func userIDRequired() bool {
+	required, _ := strconv.ParseBool(os.Getenv("USER_ID_REQUIRED"))
+	return required
+}
+
+// authorizeUser checks that the user of the USER_ID header is active and has
+// one of the given roles, or writes the error response. Requests without
+// USER_ID header are served anonymously, as they were before users existed,
+// and get an empty user, unless USER_ID_REQUIRED is true.
+func authorizeUser(c echo.Context, roles ...string) (User, bool, error) {
+	userID := c.Request().Header.Get("USER_ID")
+	if userID == "" {
+		if userIDRequired() {
+			return User{}, false, c.JSON(newSdcError("MISSING_INFORMATION"))
+		}
+		return User{}, true, nil
+	}
+	i := findUserIndex(userID)
+	if i < 0 {
+		return User{}, false, userNotFound(c, userID)
+	}
+	user := userList[i]
+	if user.Status != "ACTIVE" {
+		return User{}, false, c.JSON(newSdcError("USER_INACTIVE", userID))
+	}
+	if !containsString(roles, user.Role) {
+		return User{}, false, c.JSON(newSdcError("RESTRICTED_OPERATION"))
+	}
+	return user, true, nil
+}
+
+// setLastUpdater records the caller as last updater of a component
+func setLastUpdater(r *Resource, user User) {
+	if user.UserID != "" {
+		r.LastUpdaterUserID = user.UserID
+	}
+}
+
+// checkLock refuses the modification of a checked out component by
+// another user than the one who checked it out
+func checkLock(r Resource, user User) *SdcError {
+	if user.UserID == "" || r.LastUpdaterUserID == "" || r.LastUpdaterUserID == user.UserID {
+		return nil
+	}
+	i := findUserIndex(r.LastUpdaterUserID)
+	if i < 0 {
+		return nil
+	}
+	owner := userList[i]
+	_, sdcError := newSdcError("COMPONENT_CHECKOUT_BY_ANOTHER_USER", r.Name,
+		owner.FirstName+" "+owner.LastName+"("+owner.UserID+")")
+	return sdcError
+}
+
+func invalidRole(c echo.Context, role string) error {
+	return c.JSON(newSdcError("INVALID_ROLE", role))
+}
+
+func userNotFound(c echo.Context, userID string) error {
+	return c.JSON(newSdcError("USER_NOT_FOUND", userID))
+}
+
+func getUser(c echo.Context) error {
+	i := findUserIndex(c.Param("userID"))
+	if i < 0 {
+		return userNotFound(c, c.Param("userID"))
+	}
+	return c.JSON(http.StatusOK, userList[i])
+}
+
+func getUserRole(c echo.Context) error {
+	i := findUserIndex(c.Param("userID"))
+	if i < 0 {
+		return userNotFound(c, c.Param("userID"))
+	}
+	return c.JSON(http.StatusOK, UserRole{Role: userList[i].Role})
+}
+
+// getAuthorizedUser returns the user of the USER_ID header
+func getAuthorizedUser(c echo.Context) error {
+	userID := c.Request().Header.Get("USER_ID")
+	if userID == "" {
+		return c.JSON(newSdcError("MISSING_INFORMATION"))
+	}
+	i := findUserIndex(userID)
+	if i < 0 {
+		return userNotFound(c, userID)
+	}
+	return c.JSON(http.StatusOK, userList[i])
+}
+
+func getAdminUsers(c echo.Context) error {
+	admins := []User{}
+	for _, u := range userList {
+		if u.Role == "ADMIN" && u.Status == "ACTIVE" {
+			admins = append(admins, u)
+		}
+	}
+	return c.JSON(http.StatusOK, admins)
+}
+
+// getUsers lists the active users, restricted to the roles of the
+// comma separated roles query parameter if given
+func getUsers(c echo.Context) error {
+	roles := []string{}
+	for _, role := range strings.Split(c.QueryParam("roles"), ",") {
+		if role = strings.TrimSpace(role); role == "" {
+			continue
+		}
+		if !containsString(userRoles, role) {
+			return invalidRole(c, role)
+		}
+		roles = append(roles, role)
+	}
+	users := []User{}
+	for _, u := range userList {
+		if u.Status == "ACTIVE" && (len(roles) == 0 || containsString(roles, u.Role)) {
+			users = append(users, u)
+		}
+	}
+	return c.JSON(http.StatusOK, users)
+}
+
+func postUser(c echo.Context) error {
+	if _, ok, err := authorizeUser(c, "ADMIN"); !ok {
+		return err
+	}
+	body := new(User)
+	if err := c.Bind(body); err != nil {
+		return c.JSON(newSdcError("INVALID_CONTENT"))
+	}
+	if body.UserID == "" {
+		return c.JSON(newSdcError("MISSING_INFORMATION"))
+	}
+	if body.Role == "" {
+		body.Role = "DESIGNER"
+	}
+	if !containsString(userRoles, body.Role) {
+		return invalidRole(c, body.Role)
+	}
+	if i := findUserIndex(body.UserID); i >= 0 {
+		if userList[i].Status == "ACTIVE" {
+			return c.JSON(newSdcError("USER_ALREADY_EXIST", body.UserID))
+		}
+		userList = append(userList[:i], userList[i+1:]...)
+	}
+	user := newUser(body.UserID, body.FirstName, body.LastName, body.Role)
+	if body.Email != "" {
+		user.Email = body.Email
+	}
+	userList = append(userList, user)
+	return c.JSON(http.StatusCreated, user)
+}
+
+func postUserRole(c echo.Context) error {
+	admin, ok, err := authorizeUser(c, "ADMIN")
+	if !ok {
+		return err
+	}
+	i := findUserIndex(c.Param("userID"))
+	if i < 0 {
+		return userNotFound(c, c.Param("userID"))
+	}
+	body := new(UserRole)
+	if err := c.Bind(body); err != nil {
+		return c.JSON(newSdcError("INVALID_CONTENT"))
+	}
+	if !containsString(userRoles, body.Role) {
+		return invalidRole(c, body.Role)
+	}
+	if admin.UserID == userList[i].UserID {
+		return c.JSON(newSdcError("UPDATE_USER_ADMIN_CONFLICT"))
+	}
+	userList[i].Role = body.Role
+	return c.JSON(http.StatusOK, userList[i])
+}
+
+// deleteUser deactivates a user, as SDC keeps the users that worked on
+// components
+func deleteUser(c echo.Context) error {
+	admin, ok, err := authorizeUser(c, "ADMIN")
+	if !ok {
+		return err
+	}
+	i := findUserIndex(c.Param("userID"))
+	if i < 0 || userList[i].Status != "ACTIVE" {
+		return userNotFound(c, c.Param("userID"))
+	}
+	if admin.UserID == userList[i].UserID {
+		return c.JSON(newSdcError("RESTRICTED_OPERATION"))
+	}
+	for _, r := range resourceList {
+		if r.LastUpdaterUserID == userList[i].UserID && r.LifecycleState == "NOT_CERTIFIED_CHECKOUT" {
+			return c.JSON(newSdcError("CANNOT_DELETE_USER_WITH_ACTIVE_ELEMENTS"))
+		}
+	}
+	userList[i].Status = "INACTIVE"
+	return c.JSON(http.StatusOK, userList[i])
+}
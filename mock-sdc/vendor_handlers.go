@@ -108,11 +108,19 @@ func getVendorServiceModels(c echo.Context) error {
 }
 
 func postVendorServiceModels(c echo.Context) error {
+	user, ok, err := authorizeUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return err
+	}
 	newVendor := new(NewVendor)
 	if err := c.Bind(newVendor); err != nil {
 		return err
 	}
 
+	owner := user.UserID
+	if owner == "" {
+		owner = "cs0008"
+	}
 	u1 := uuid.NewV4().String()
 	version := Version{
 		ID:               u1,
@@ -142,7 +150,7 @@ func postVendorServiceModels(c echo.Context) error {
 		Type:        "vlm",
 		Name:        newVendor.VendorName,
 		Description: newVendor.Description,
-		Owner:       "cs0008",
+		Owner:       owner,
 		Status:      "ACTIVE",
 		Properties:  empty,
 		Versions:    []Version{version}})
@@ -0,0 +1,231 @@
+// Copyright 2023 Deutsche Telekom AG, Orange
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ComponentInstanceVersionChange is the body of a changeVersion request
+type ComponentInstanceVersionChange struct {
+	ComponentUID string `json:"componentUid"`
+}
+
+// parseVersion splits a "major.minor" component version
+func parseVersion(version string) (int, int) {
+	majorPart, minorPart, _ := strings.Cut(version, ".")
+	major, _ := strconv.Atoi(majorPart)
+	minor, _ := strconv.Atoi(minorPart)
+	return major, minor
+}
+
+// compareVersions returns a negative, zero or positive number when version
+// a is lower than, equal to or greater than version b
+func compareVersions(a string, b string) int {
+	majorA, minorA := parseVersion(a)
+	majorB, minorB := parseVersion(b)
+	if majorA != majorB {
+		return majorA - majorB
+	}
+	return minorA - minorB
+}
+
+// nextMajorVersion is the version a component gets when certified
+func nextMajorVersion(version string) string {
+	major, _ := parseVersion(version)
+	return strconv.Itoa(major+1) + ".0"
+}
+
+// nextMinorVersion is the version a certified component gets on checkout
+func nextMinorVersion(version string) string {
+	major, minor := parseVersion(version)
+	return strconv.Itoa(major) + "." + strconv.Itoa(minor+1)
+}
+
+func copyArtifacts(artifacts map[string]Artifact) map[string]Artifact {
+	copied := map[string]Artifact{}
+	for label, a := range artifacts {
+		copied[label] = a
+	}
+	return copied
+}
+
+// copyResource returns a copy of a component that shares no collection with
+// it, so that a new version can be changed without changing the previous one
+func copyResource(r Resource) Resource {
+	copied := r
+	copied.Artifacts = copyArtifacts(r.Artifacts)
+	copied.DeploymentArtifacts = copyArtifacts(r.DeploymentArtifacts)
+	copied.ToscaArtifacts = copyArtifacts(r.ToscaArtifacts)
+	copied.ServiceAPIArtifacts = copyArtifacts(r.ServiceAPIArtifacts)
+	copied.Properties = append([]Property(nil), r.Properties...)
+	copied.Inputs = append([]Input(nil), r.Inputs...)
+	copied.Tags = append([]string(nil), r.Tags...)
+	copied.ComponentInstances = nil
+	for _, ci := range r.ComponentInstances {
+		ci.DeploymentArtifacts = copyArtifacts(ci.DeploymentArtifacts)
+		groupInstances := []GroupInstance{}
+		for _, g := range ci.GroupInstances {
+			g.Properties = append([]Property{}, g.Properties...)
+			groupInstances = append(groupInstances, g)
+		}
+		ci.GroupInstances = groupInstances
+		ci.Properties = append([]Property{}, ci.Properties...)
+		ci.Inputs = append([]Input{}, ci.Inputs...)
+		copied.ComponentInstances = append(copied.ComponentInstances, ci)
+	}
+	copied.ComponentInstancesProperties = map[string][]Property{}
+	for id, properties := range r.ComponentInstancesProperties {
+		copied.ComponentInstancesProperties[id] = append([]Property{}, properties...)
+	}
+	copied.ComponentInstancesInputs = map[string][]Input{}
+	for id, inputs := range r.ComponentInstancesInputs {
+		copied.ComponentInstancesInputs[id] = append([]Input{}, inputs...)
+	}
+	copied.ComponentInstancesRelations = nil
+	for _, relation := range r.ComponentInstancesRelations {
+		relation.Relationships = append([]CapabilityRequirementRelationship{}, relation.Relationships...)
+		copied.ComponentInstancesRelations = append(copied.ComponentInstancesRelations, relation)
+	}
+	copied.Groups = nil
+	for _, g := range r.Groups {
+		members := map[string]string{}
+		for name, id := range g.Members {
+			members[name] = id
+		}
+		g.Members = members
+		g.Artifacts = append([]string{}, g.Artifacts...)
+		g.ArtifactsUUID = append([]string{}, g.ArtifactsUUID...)
+		g.Properties = append([]Property{}, g.Properties...)
+		copied.Groups = append(copied.Groups, g)
+	}
+	initResourceCollections(&copied)
+	return copied
+}
+
+// newResourceVersion checks a certified component out: a new minor version
+// sharing its invariant UUID is added to the catalog, and its index returned
+func newResourceVersion(i int) int {
+	r := copyResource(resourceList[i])
+	r.ID = uuid.NewV4().String()
+	r.UniqueID = uuid.NewV4().String()
+	r.Version = nextMinorVersion(resourceList[i].Version)
+	r.LifecycleState = "NOT_CERTIFIED_CHECKOUT"
+	r.DistributionStatus = "DISTRIBUTION_NOT_APPROVED"
+	r.DistributionID = ""
+	r.Distributions = nil
+	r.DistributerUserID = ""
+	r.DistributionDecision = nil
+	r.ToscaModelURL = "/sdc/v1/catalog/" + catalogCollection(r) + "/" + r.ID + "/toscaModel"
+	resourceList = append(resourceList, r)
+	return len(resourceList) - 1
+}
+
+// componentVersions returns the unique ids of all the versions of a
+// component, by version
+func componentVersions(invariantID string) map[string]string {
+	versions := map[string]string{}
+	for _, r := range resourceList {
+		if r.InvariantID == invariantID {
+			versions[r.Version] = r.UniqueID
+		}
+	}
+	return versions
+}
+
+// isHighestVersion tells whether no other version of a component is newer
+func isHighestVersion(r Resource) bool {
+	for version := range componentVersions(r.InvariantID) {
+		if compareVersions(version, r.Version) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// postChangeVersion moves a component instance to another certified version
+// of its origin. Properties and inputs keep their values when they still
+// exist with the same type.
+func postChangeVersion(c echo.Context) error {
+	i, user, err := checkoutResourceIndex(c)
+	if i < 0 {
+		return err
+	}
+	instanceID := c.Param("instanceID")
+	j := findComponentInstance(resourceList[i].ComponentInstances, instanceID)
+	if j < 0 {
+		return c.JSON(newSdcError("COMPONENT_INSTANCE_NOT_FOUND", instanceID))
+	}
+	body := new(ComponentInstanceVersionChange)
+	if err := c.Bind(body); err != nil {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Invalid content.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	k := findResourceIndex(body.ComponentUID)
+	if k < 0 {
+		return c.JSON(http.StatusNotFound, SdcError{
+			Message:   "Resource not found",
+			ErrorCode: "SVC4642",
+			Status:    "Not Found"})
+	}
+	target := resourceList[k]
+	ci := resourceList[i].ComponentInstances[j]
+	origin, found := instanceOrigin(ci)
+	if !found || origin.InvariantID != target.InvariantID {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Component " + target.Name + " " + target.Version + " is not a version of " + ci.ComponentName + ".",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+	if target.LifecycleState != "CERTIFIED" || compareVersions(target.Version, origin.Version) <= 0 {
+		return c.JSON(http.StatusBadRequest, SdcError{
+			Message:   "Version " + target.Version + " of " + target.Name + " is not a newer certified version.",
+			ErrorCode: "SVC4000",
+			Status:    "Invalid Content"})
+	}
+
+	r := &resourceList[i]
+	changed := newComponentInstance(ci.Name, target)
+	changed.UniqueID = ci.UniqueID
+	changed.InvariantName = ci.InvariantName
+	changed.DeploymentArtifacts = ci.DeploymentArtifacts
+	changed.GroupInstances = vfModuleInstances(changed, target)
+	previousProperties := r.ComponentInstancesProperties[ci.UniqueID]
+	previousInputs := r.ComponentInstancesInputs[ci.UniqueID]
+	r.ComponentInstances[j] = changed
+	addInstanceProperties(r, changed, target)
+	properties := r.ComponentInstancesProperties[ci.UniqueID]
+	for n, p := range properties {
+		if m := findProperty(previousProperties, p.Name); m >= 0 && previousProperties[m].Type == p.Type {
+			properties[n].Value = previousProperties[m].Value
+		}
+	}
+	inputs := r.ComponentInstancesInputs[ci.UniqueID]
+	for n, input := range inputs {
+		if m := findInput(previousInputs, input.Name); m >= 0 && previousInputs[m].Type == input.Type {
+			inputs[n].Value = previousInputs[m].Value
+		}
+	}
+	refreshComponentInstance(r, ci.UniqueID)
+	setLastUpdater(r, user)
+	return c.JSON(http.StatusOK, r.ComponentInstances[j])
+}
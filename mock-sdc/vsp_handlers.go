@@ -15,6 +15,8 @@
 package main
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -23,28 +25,45 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
-// Vsp describes software product in SDC
+// ImportStructure tells which kinds of templates an onboarded package has
+type ImportStructure struct {
+	Heat string `json:"heat,omitempty"`
+	Helm string `json:"helm,omitempty"`
+}
+
+// ValidationData is the result of the validation of an onboarded package,
+// with the checks of the hashes and signatures of ETSI packages and their
+// errors by file
+type ValidationData struct {
+	ImportStructure ImportStructure           `json:"importStructure"`
+	Security        *SecurityValidation       `json:"security,omitempty"`
+	Errors          map[string][]ErrorMessage `json:"errors,omitempty"`
+}
+
+// Vsp describes software product in SDC. The Components and
+// DeploymentFlavors of a manual VSP are its model, from which its Package is
+// generated when submitted.
 type Vsp struct {
-	ID                        string `json:"id"`
-	Icon                      string `json:"icon"`
-	OnboardingMethod          string `json:"onboardingMethod"`
-	Name                      string `json:"name"`
-	Description               string `json:"description"`
-	Owner                     string `json:"owner"`
-	Status                    string `json:"status"`
-	VendorName                string `json:"vendorName"`
-	VendorID                  string `json:"vendorId"`
-	Category                  string `json:"category"`
-	SubCategory               string `json:"subCategory"`
-	CandidateOnboardingOrigin string `json:"candidateOnboardingOrigin"`
-	OnboardingOrigin          string `json:"onboardingOrigin"`
-	NetworkPackageName        string `json:"networkPackageName"`
-	ValidationData            struct {
-		ImportStructure struct {
-			Heat string `json:"heat"`
-		} `json:"importStructure"`
-	} `json:"validationData"`
-	Versions []Version `json:"-"`
+	ID                        string             `json:"id"`
+	Icon                      string             `json:"icon"`
+	OnboardingMethod          string             `json:"onboardingMethod"`
+	Name                      string             `json:"name"`
+	Description               string             `json:"description"`
+	Owner                     string             `json:"owner"`
+	Status                    string             `json:"status"`
+	VendorName                string             `json:"vendorName"`
+	VendorID                  string             `json:"vendorId"`
+	Category                  string             `json:"category"`
+	SubCategory               string             `json:"subCategory"`
+	CandidateOnboardingOrigin string             `json:"candidateOnboardingOrigin"`
+	OnboardingOrigin          string             `json:"onboardingOrigin"`
+	NetworkPackageName        string             `json:"networkPackageName"`
+	ValidationData            ValidationData     `json:"validationData"`
+	Versions                  []Version          `json:"-"`
+	Payload                   []byte             `json:"-"`
+	Package                   *OnboardedPackage  `json:"-"`
+	Components                []VspComponent     `json:"-"`
+	DeploymentFlavors         []DeploymentFlavor `json:"-"`
 }
 
 // VspLight describes software product in SDC lists
@@ -97,23 +116,19 @@ type VspDetailsUploaded struct {
 
 // VspDetailsValidated describes software product in SDC
 type VspDetailsValidated struct {
-	ID                 string `json:"id"`
-	Icon               string `json:"icon"`
-	OnboardingMethod   string `json:"onboardingMethod"`
-	Name               string `json:"name"`
-	Description        string `json:"description"`
-	VendorName         string `json:"vendorName"`
-	VendorID           string `json:"vendorId"`
-	Version            string `json:"version"`
-	Category           string `json:"category"`
-	SubCategory        string `json:"subCategory"`
-	OnboardingOrigin   string `json:"onboardingOrigin"`
-	NetworkPackageName string `json:"networkPackageName"`
-	ValidationData     struct {
-		ImportStructure struct {
-			Heat string `json:"heat"`
-		} `json:"importStructure"`
-	} `json:"validationData"`
+	ID                 string         `json:"id"`
+	Icon               string         `json:"icon"`
+	OnboardingMethod   string         `json:"onboardingMethod"`
+	Name               string         `json:"name"`
+	Description        string         `json:"description"`
+	VendorName         string         `json:"vendorName"`
+	VendorID           string         `json:"vendorId"`
+	Version            string         `json:"version"`
+	Category           string         `json:"category"`
+	SubCategory        string         `json:"subCategory"`
+	OnboardingOrigin   string         `json:"onboardingOrigin"`
+	NetworkPackageName string         `json:"networkPackageName"`
+	ValidationData     ValidationData `json:"validationData"`
 }
 
 // NewVsp describe the vsp creation model in SDC
@@ -137,11 +152,17 @@ type ArtifactUploadResult struct {
 	NetworkPackageName string   `json:"networkPackageName"`
 }
 
+// ErrorMessage is an error found while validating an onboarded package
+type ErrorMessage struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
 // ArtifactValidationResult bla
 type ArtifactValidationResult struct {
-	Errors    struct{} `json:"errors"`
-	Status    string   `json:"status"`
-	FileNames []string `json:"fileNames"`
+	Errors    map[string][]ErrorMessage `json:"errors"`
+	Status    string                    `json:"status"`
+	FileNames []string                  `json:"fileNames"`
 }
 
 // CsarCreateResult bla
@@ -164,6 +185,16 @@ func generateInitialVspList() {
 	vspList = []Vsp{}
 }
 
+// vspPackage returns the package validated for the VSP vspID, if any
+func vspPackage(vspID string) *OnboardedPackage {
+	for _, v := range vspList {
+		if v.ID == vspID {
+			return v.Package
+		}
+	}
+	return nil
+}
+
 func getVendorSoftwareProducts(c echo.Context) error {
 	vspLights := []VspLight{}
 	for _, v := range vspList {
@@ -183,11 +214,32 @@ func getVendorSoftwareProducts(c echo.Context) error {
 }
 
 func postVendorSoftwareProducts(c echo.Context) error {
+	user, ok, err := authorizeUser(c, "DESIGNER", "ADMIN")
+	if !ok {
+		return err
+	}
 	newVsp := new(NewVsp)
 	if err := c.Bind(newVsp); err != nil {
 		return err
 	}
+	if newVsp.OnboardingMethod == "" {
+		newVsp.OnboardingMethod = "NetworkPackage"
+	}
+	if newVsp.OnboardingMethod != "NetworkPackage" && newVsp.OnboardingMethod != "Manual" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid onboarding method "+newVsp.OnboardingMethod)
+	}
+	if newVsp.Category == "" && newVsp.SubCategory == "" {
+		newVsp.Category = "resourceNewCategory.generic"
+		newVsp.SubCategory = "resourceNewCategory.generic.abstract"
+	}
+	if err := validateVspCategory(newVsp.Category, newVsp.SubCategory); err != nil {
+		return err
+	}
 
+	owner := user.UserID
+	if owner == "" {
+		owner = "cs0008"
+	}
 	u1 := uuid.NewV4().String()
 	version := Version{
 		ID:               u1,
@@ -213,15 +265,15 @@ func postVendorSoftwareProducts(c echo.Context) error {
 	u2 := uuid.NewV4().String()
 	vspList = append(vspList, Vsp{
 		ID:               u2,
-		OnboardingMethod: "NetworkPackage",
+		OnboardingMethod: newVsp.OnboardingMethod,
 		Name:             newVsp.Name,
 		Description:      newVsp.Description,
-		Owner:            "cs0008",
+		Owner:            owner,
 		Status:           "ACTIVE",
 		VendorName:       newVsp.VendorName,
 		VendorID:         newVsp.VendorID,
-		Category:         "resourceNewCategory.generic",
-		SubCategory:      "resourceNewCategory.generic.abstract",
+		Category:         newVsp.Category,
+		SubCategory:      newVsp.SubCategory,
 		Icon:             "icon",
 		Versions:         []Version{version}})
 
@@ -242,6 +294,9 @@ func uploadArtifacts(c echo.Context) error {
 	versionID := c.Param("versionID")
 	for i, v := range vspList {
 		if v.ID == vspID {
+			if v.OnboardingMethod == "Manual" {
+				return echo.NewHTTPError(http.StatusBadRequest, "VSP "+v.Name+" is onboarded manually")
+			}
 			for j, version := range v.Versions {
 				if version.ID == versionID {
 					if version.RealStatus == "Draft" {
@@ -249,6 +304,16 @@ func uploadArtifacts(c echo.Context) error {
 						if err != nil {
 							return err
 						}
+						src, err := file.Open()
+						if err != nil {
+							return err
+						}
+						payload, err := io.ReadAll(src)
+						src.Close()
+						if err != nil {
+							return err
+						}
+						vspList[i].Payload = payload
 						fileName := strings.Split(file.Filename, ".")[0]
 						fileExtension := strings.Split(file.Filename, ".")[1]
 						vspList[i].NetworkPackageName = fileName
@@ -278,28 +343,45 @@ func validateArtifacts(c echo.Context) error {
 			for j, version := range v.Versions {
 				if version.ID == versionID {
 					if version.RealStatus == "Uploaded" {
+						fileNames := []string{
+							"base_ubuntu16.env",
+							"base_ubuntu16.yaml",
+						}
+						onboardedPackage, err := parseOnboardedPackage(v.Payload)
+						if err == nil {
+							fileNames = onboardedPackage.fileNames()
+							vspList[i].Package = onboardedPackage
+						} else if v.Payload != nil && !errors.Is(err, errNotZip) {
+							return c.JSON(http.StatusOK, ArtifactValidationResult{
+								Errors: map[string][]ErrorMessage{
+									"uploadFile": {{Level: "ERROR", Message: err.Error()}},
+								},
+								FileNames: []string{},
+								Status:    "Failure",
+							})
+						}
 						vspList[i].OnboardingOrigin = vspList[i].CandidateOnboardingOrigin
 						vspList[i].Versions[j].State.Dirty = true
-						vspList[i].ValidationData = struct {
-							ImportStructure struct {
-								Heat string `json:"heat"`
-							} `json:"importStructure"`
-						}{
-							ImportStructure: struct {
-								Heat string `json:"heat"`
-							}{
-								Heat: "Yes",
-							},
+						vspList[i].ValidationData = ValidationData{}
+						if onboardedPackage == nil || !onboardedPackage.hasType("HELM") || onboardedPackage.hasType("HEAT") {
+							vspList[i].ValidationData.ImportStructure.Heat = "Yes"
+						}
+						if onboardedPackage != nil && onboardedPackage.hasType("HELM") {
+							vspList[i].ValidationData.ImportStructure.Helm = "Yes"
+						}
+						if onboardedPackage != nil {
+							vspList[i].ValidationData.Security = onboardedPackage.Security
+							vspList[i].ValidationData.Errors = onboardedPackage.SecurityErrors
 						}
 						vspList[i].Versions[j].RealStatus = "Validated"
-						var empty struct{}
 						artifactValidationResult := ArtifactValidationResult{
-							Errors: empty,
-							FileNames: []string{
-								"base_ubuntu16.env",
-								"base_ubuntu16.yaml",
-							},
-							Status: "Success",
+							Errors:    map[string][]ErrorMessage{},
+							FileNames: fileNames,
+							Status:    "Success",
+						}
+						if len(vspList[i].ValidationData.Errors) != 0 {
+							artifactValidationResult.Errors = vspList[i].ValidationData.Errors
+							artifactValidationResult.Status = "Failure"
 						}
 						return c.JSON(http.StatusOK, artifactValidationResult)
 					}
@@ -322,6 +404,17 @@ func updateVspVersion(c echo.Context) error {
 			for j, version := range v.Versions {
 				if version.ID == versionID {
 					if action.Action == "Submit" {
+						if len(v.ValidationData.Errors) != 0 {
+							return echo.NewHTTPError(http.StatusBadRequest, "Item has validation errors")
+						}
+						if version.RealStatus == "Commited" && v.OnboardingMethod == "Manual" {
+							onboardedPackage, err := manualPackage(v)
+							if err != nil {
+								return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+							}
+							vspList[i].Package = onboardedPackage
+							vspList[i].ValidationData = ValidationData{ImportStructure: ImportStructure{Heat: "Yes"}}
+						}
 						if version.RealStatus == "Commited" {
 							vspList[i].Versions[j].RealStatus = "Certified"
 							vspList[i].Versions[j].Status = "Certified"
@@ -331,6 +424,10 @@ func updateVspVersion(c echo.Context) error {
 					}
 					if action.Action == "Create_Package" {
 						if version.RealStatus == "Certified" {
+							resourceType := "VF"
+							if v.Package != nil {
+								resourceType = v.Package.ResourceType
+							}
 							csarCreateResult := CsarCreateResult{
 								Description:   v.Description,
 								VspName:       v.Name,
@@ -341,7 +438,7 @@ func updateVspVersion(c echo.Context) error {
 								VendorName:    v.VendorName,
 								VendorRelease: "1.0",
 								PackageType:   "CSAR",
-								ResourceType:  "VF",
+								ResourceType:  resourceType,
 							}
 							return c.JSON(http.StatusOK, csarCreateResult)
 						}